@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	lowRating         = 2
+	alertBaseline     = 7 * 24 * time.Hour
+	alertSampleLength = 3
+)
+
+type reviewRate struct {
+	low     int
+	total   int
+	samples []handlers.UserReview
+}
+
+func (r reviewRate) rate() float64 {
+	if r.total == 0 {
+		return 0
+	}
+	return float64(r.low) / float64(r.total)
+}
+
+func (r *reviewRate) add(review handlers.UserReview, keepSample bool) {
+	r.total++
+	if review.Rating > lowRating {
+		return
+	}
+	r.low++
+	if keepSample && len(r.samples) < alertSampleLength {
+		r.samples = append(r.samples, review)
+	}
+}
+
+func checkAlerts(app handlers.Application, respChannel chan tgbotapi.Chattable) {
+	settings := app.GetAlertSettings()
+	if settings.Disabled || app.ChatId == 0 {
+		return
+	}
+
+	now := time.Now()
+	windowStart := now.Add(-time.Duration(settings.WindowHours) * time.Hour)
+
+	var reviews []handlers.UserReview
+	datastore.Use(func(store *datastore.Datastore) {
+		c, err := store.DB().Collection(collections.REVIEWS).Find(store.Context, bson.M{
+			"appid": app.ID,
+			"fetched": bson.M{
+				"$gte": now.Add(-alertBaseline),
+			},
+			// reviews imported from reports are old, they would look like a spike
+			"imported": bson.M{"$ne": true},
+		}, options.Find().SetSort(bson.M{"fetched": -1}))
+		utils.PanicOnError(err)
+
+		err = c.All(store.Context, &reviews)
+		utils.PanicOnError(err)
+	})
+
+	var window, baseline reviewRate
+	windowByVersion := map[string]*reviewRate{}
+	baselineByVersion := map[string]*reviewRate{}
+	for _, r := range reviews {
+		if r.FetchedOrTime().Before(windowStart) {
+			baseline.add(r, false)
+			addVersionRate(baselineByVersion, r, false)
+		} else {
+			window.add(r, true)
+			addVersionRate(windowByVersion, r, true)
+		}
+	}
+
+	var versions []string
+	for version := range windowByVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	versionAlerted := false
+	for _, version := range versions {
+		versionBaseline := baseline
+		if b, ok := baselineByVersion[version]; ok && b.total > 0 {
+			versionBaseline = *b
+		}
+		if raiseAlert(app, settings, version, *windowByVersion[version], versionBaseline, respChannel) {
+			versionAlerted = true
+		}
+	}
+
+	if !versionAlerted {
+		raiseAlert(app, settings, "", window, baseline, respChannel)
+	}
+}
+
+func addVersionRate(rates map[string]*reviewRate, review handlers.UserReview, keepSample bool) {
+	if review.AppVersion == "" {
+		return
+	}
+	rate, ok := rates[review.AppVersion]
+	if !ok {
+		rate = &reviewRate{}
+		rates[review.AppVersion] = rate
+	}
+	rate.add(review, keepSample)
+}
+
+func raiseAlert(app handlers.Application,
+	settings handlers.AlertSettings,
+	version string,
+	window reviewRate,
+	baseline reviewRate,
+	respChannel chan tgbotapi.Chattable) bool {
+
+	if window.low < settings.MinCount {
+		return false
+	}
+	if baseline.total > 0 && window.rate() < baseline.rate()*settings.Ratio {
+		return false
+	}
+
+	alert := handlers.Alert{
+		AppId:        app.ID,
+		ChatId:       app.ChatId,
+		Version:      version,
+		LowCount:     window.low,
+		Total:        window.total,
+		Rate:         window.rate(),
+		BaselineRate: baseline.rate(),
+		Created:      time.Now(),
+	}
+
+	inCooldown := false
+	datastore.Use(func(store *datastore.Datastore) {
+		err := store.DB().Collection(collections.ALERTS).FindOne(store.Context, bson.M{
+			"appid":   app.ID,
+			"version": version,
+			"created": bson.M{
+				"$gte": alert.Created.Add(-time.Duration(settings.CooldownHours) * time.Hour),
+			},
+		}).Err()
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		utils.PanicOnError(err)
+		inCooldown = true
+	})
+	if inCooldown {
+		log.Printf("[%s] Alert for version %q is in cooldown", app.PackageName, version)
+		return true
+	}
+
+	var chatSettings handlers.ChatSettings
+	datastore.Use(func(store *datastore.Datastore) {
+		res, err := store.DB().Collection(collections.ALERTS).InsertOne(store.Context, alert)
+		utils.PanicOnError(err)
+
+		alert.ID = res.InsertedID.(primitive.ObjectID)
+		chatSettings = handlers.LoadChatSettings(store, app.ChatId)
+	})
+
+	message := tgbotapi.NewMessage(app.ChatId, formatAlert(app, alert, window.samples, chatSettings))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup([]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(i18n.T(chatSettings.Language, "Acknowledge"), handlers.AcknowledgeAlertPrefix+alert.ID.Hex()),
+	})
+
+	log.Printf("[%s] Raising alert for version %q", app.PackageName, version)
+	respChannel <- handlers.InThread(message, app.MessageThreadId)
+
+	return true
+}
+
+func formatAlert(app handlers.Application, alert handlers.Alert, samples []handlers.UserReview, settings handlers.ChatSettings) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(i18n.T(settings.Language, "🚨🚨🚨 NEGATIVE REVIEW SPIKE 🚨🚨🚨") + "\n")
+	buffer.WriteString(app.GetName())
+	if alert.Version != "" {
+		buffer.WriteString(" ")
+		buffer.WriteString(alert.Version)
+	}
+	buffer.WriteString("\n")
+	buffer.WriteString(i18n.T(settings.Language, "%d of %d reviews rated %d★ or lower (%.0f%%, baseline %.0f%%)",
+		alert.LowCount,
+		alert.Total,
+		lowRating,
+		alert.Rate*100,
+		alert.BaselineRate*100,
+	) + "\n")
+
+	for _, r := range samples {
+		buffer.WriteString("\n")
+		buffer.WriteString(r.FormatIn(settings))
+		buffer.WriteString("\n")
+	}
+
+	return buffer.String()
+}
@@ -6,12 +6,14 @@ import (
 	"google-play-review-bot/collections"
 	"google-play-review-bot/datastore"
 	"google-play-review-bot/handlers"
+	"google-play-review-bot/notifier"
 	"google-play-review-bot/scheduler"
 	"google-play-review-bot/utils"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bugsnag/bugsnag-go"
@@ -34,6 +36,7 @@ type entry struct {
 	Rating  wrappedText  `json:"im:rating"`
 	Title   wrappedText  `json:"title"`
 	Content wrappedText  `json:"content"`
+	Updated wrappedText  `json:"updated"`
 }
 
 type reviewAuthor struct {
@@ -123,15 +126,25 @@ func (i IosAppObserver) requestReviews(app handlers.Application, respChannel cha
 			continue
 		}
 
-		review := userReview{
+		// the review is still posted without the time it was written
+		updated, err := time.Parse(time.RFC3339, string(rssEntry.Updated))
+		utils.LogError(err)
+
+		review := handlers.UserReview{
+			AppId:      app.ID,
+			ReviewId:   string(rssEntry.ID),
 			UserName:   string(rssEntry.Author.Name),
 			Text:       fmt.Sprintf("%s\n%s", rssEntry.Title, rssEntry.Content),
+			Time:       updated,
 			Rating:     int(rating),
 			AppVersion: string(rssEntry.Version),
 			AppName:    app.GetName(),
+			Country:    strings.ToUpper(app.AppStoreCountryCode),
+			Url:        fmt.Sprintf("https://apps.apple.com/%s/app/id%s?see-all=reviews", app.AppStoreCountryCode, app.PackageName),
 		}
+		review = saveReview(review)
 
-		respChannel <- tgbotapi.NewMessage(app.ChatId, review.format())
+		notifier.Notify(app, review, respChannel)
 
 		if processedReviewID == "" {
 			processedReviewID = string(rssEntry.ID)
@@ -153,6 +166,8 @@ func (i IosAppObserver) requestReviews(app handlers.Application, respChannel cha
 			utils.PanicOnError(err)
 		})
 	}
+
+	checkAlerts(app, respChannel)
 }
 
 func (i IosAppObserver) Observe(respChannel chan tgbotapi.Chattable, appCollectionUpdate chan int) {
@@ -4,4 +4,9 @@ const (
 	CHAT = "chat"
 	APPS = "apps"
 	MESSAGE_LOG = "message_log"
+	REVIEWS = "reviews"
+	ALERTS = "alerts"
+	SEARCHES = "searches"
+	DELIVERIES = "deliveries"
+	CHAT_SETTINGS = "chat_settings"
 )
\ No newline at end of file
@@ -24,7 +24,8 @@ type Datastore struct {
 	Context context.Context
 }
 
-func init() {
+// Connect opens the connection shared by all stores, it is called by main so packages can be tested without a database.
+func Connect() {
 	var err error
 	mongoHost := os.Getenv("MONGO_HOST")
 
@@ -63,6 +64,46 @@ func init() {
 
 		_, err = DB().Collection(collections.APPS).Indexes().CreateOne(store.Context, appIndex)
 		utils.PanicOnError(err)
+
+		reviewIndex := mongo.IndexModel{
+			Keys:    bson.D{{Key: "appid", Value: 1}, {Key: "reviewid", Value: 1}},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		}
+
+		_, err = DB().Collection(collections.REVIEWS).Indexes().CreateOne(store.Context, reviewIndex)
+		utils.PanicOnError(err)
+
+		reviewTextIndex := mongo.IndexModel{
+			Keys:    bson.D{{Key: "text", Value: "text"}, {Key: "username", Value: "text"}},
+			Options: options.Index().SetName("reviews_text").SetBackground(true),
+		}
+
+		_, err = DB().Collection(collections.REVIEWS).Indexes().CreateOne(store.Context, reviewTextIndex)
+		utils.PanicOnError(err)
+
+		deliveryIndex := mongo.IndexModel{
+			Keys:    bson.D{{Key: "created", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(30 * 24 * 60 * 60).SetBackground(true),
+		}
+
+		_, err = DB().Collection(collections.DELIVERIES).Indexes().CreateOne(store.Context, deliveryIndex)
+		utils.PanicOnError(err)
+
+		alertIndex := mongo.IndexModel{
+			Keys:    bson.D{{Key: "appid", Value: 1}, {Key: "version", Value: 1}, {Key: "created", Value: -1}},
+			Options: options.Index().SetBackground(true),
+		}
+
+		_, err = DB().Collection(collections.ALERTS).Indexes().CreateOne(store.Context, alertIndex)
+		utils.PanicOnError(err)
+
+		chatSettingsIndex := mongo.IndexModel{
+			Keys:    bson.D{{Key: "chatid", Value: 1}},
+			Options: options.Index().SetUnique(true).SetBackground(true),
+		}
+
+		_, err = DB().Collection(collections.CHAT_SETTINGS).Indexes().CreateOne(store.Context, chatSettingsIndex)
+		utils.PanicOnError(err)
 	}()
 
 	updateAppType()
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/notifier"
+	"google-play-review-bot/scheduler"
+	"google-play-review-bot/utils"
+	"log"
+	"time"
+
+	"github.com/bugsnag/bugsnag-go"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var digestPeriods = map[string]time.Duration{
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+func scheduleDigests() {
+	scheduler.NewScheduler().Schedule(sendDigests, time.Hour)
+}
+
+func sendDigests() {
+	defer bugsnag.AutoNotify()
+
+	var apps []handlers.Application
+	datastore.Use(func(store *datastore.Datastore) {
+		c, err := store.DB().Collection(collections.APPS).Find(store.Context, bson.M{
+			"destinations.digest": bson.M{
+				"$exists": true,
+			},
+		})
+		utils.PanicOnError(err)
+
+		err = c.All(store.Context, &apps)
+		utils.PanicOnError(err)
+	})
+
+	now := time.Now()
+	for _, app := range apps {
+		for _, destination := range app.Destinations {
+			period, ok := digestPeriods[destination.Digest]
+			if !ok {
+				continue
+			}
+			since := now.Add(-period)
+			if destination.LastDigest != nil {
+				since = *destination.LastDigest
+			}
+			if now.Sub(since) < period {
+				continue
+			}
+
+			sendDigest(app, destination, since, now)
+		}
+	}
+}
+
+func sendDigest(app handlers.Application, destination handlers.Destination, since time.Time, until time.Time) {
+	var reviews []handlers.UserReview
+	datastore.Use(func(store *datastore.Datastore) {
+		c, err := store.DB().Collection(collections.REVIEWS).Find(store.Context, bson.M{
+			"appid": app.ID,
+			"fetched": bson.M{
+				"$gt":  since,
+				"$lte": until,
+			},
+			"imported": bson.M{
+				"$exists": false,
+			},
+		}, options.Find().SetSort(bson.M{"fetched": 1}))
+		utils.PanicOnError(err)
+
+		err = c.All(store.Context, &reviews)
+		utils.PanicOnError(err)
+	})
+
+	if len(reviews) > 0 {
+		log.Printf("[%s] Sending digest of %d reviews to %s", app.PackageName, len(reviews), destination.Describe())
+		if err := notifier.NotifyDigest(app, destination, reviews); err != nil {
+			utils.LogError(err)
+			return
+		}
+	}
+
+	datastore.Use(func(store *datastore.Datastore) {
+		_, err := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{
+			"_id":             app.ID,
+			"destinations.id": destination.ID,
+		}, bson.M{
+			"$set": bson.M{
+				"destinations.$.lastdigest": until,
+			},
+		})
+		utils.LogError(err)
+	})
+}
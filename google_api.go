@@ -1,15 +1,14 @@
 package main
 
 import (
-	"bytes"
 	"fmt"
 	"google-play-review-bot/collections"
 	"google-play-review-bot/datastore"
 	"google-play-review-bot/handlers"
+	"google-play-review-bot/notifier"
 	"google-play-review-bot/scheduler"
 	"google-play-review-bot/utils"
 	"log"
-	"strings"
 	"time"
 
 	"github.com/bugsnag/bugsnag-go"
@@ -20,131 +19,6 @@ import (
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
-type userReview struct {
-	UserName       string
-	Device         string
-	SdkInt         int
-	Text           string
-	Time           time.Time
-	Rating         int
-	AppVersion     string
-	AppBuildNumber int64
-	AppName        string
-}
-
-func (r userReview) format() string {
-	var buffer bytes.Buffer
-
-	var header string
-	if r.Time.IsZero() {
-		header = fmt.Sprintf("%s %s\n",
-			r.AppName,
-			r.AppVersion,
-		)
-	} else {
-		timeFormatted := r.Time.Format("2006-01-02 15:04")
-
-		header = fmt.Sprintf("%s %s (%d) at %s\n",
-			r.AppName,
-			r.AppVersion,
-			r.AppBuildNumber,
-			timeFormatted,
-		)
-	}
-
-	buffer.WriteString(header)
-
-	if len(r.UserName) > 0 {
-		buffer.WriteString(r.UserName)
-		buffer.WriteString("\n")
-	}
-
-	if r.Device != "" {
-		buffer.WriteString("Device: ")
-		buffer.WriteString(r.Device)
-	}
-	if r.SdkInt > 0 {
-		buffer.WriteString(" on Android ")
-		buffer.WriteString(sdkIntToString(r.SdkInt))
-	}
-	if r.Device != "" || r.SdkInt > 0 {
-		buffer.WriteString("\n")
-	}
-
-	hearticon := "💔"
-	if r.Rating > 3 {
-		hearticon = "❤️"
-	}
-
-	for i := 1; i <= r.Rating; i++ {
-		buffer.WriteString(hearticon)
-	}
-
-	// for i := r.Rating; i < 5; i++ {
-	// 	buffer.WriteString("☆")
-	// }
-
-	if len(r.Text) > 0 {
-		buffer.WriteString("\n")
-		buffer.WriteString(strings.TrimSpace(r.Text))
-	}
-
-	return buffer.String()
-}
-
-func sdkIntToString(sdkInt int) string {
-	switch sdkInt {
-	case 36:
-		return "16"
-	case 35:
-		return "15"
-	case 34:
-		return "14"
-	case 33:
-		return "13"
-	case 32:
-		return "12L"
-	case 31:
-		return "12"
-	case 30:
-		return "11"
-	case 29:
-		return "10"
-	case 28:
-		return "9"
-	case 27:
-		return "8.1"
-	case 26:
-		return "8.0"
-	case 25:
-		return "7.1"
-	case 24:
-		return "7.0"
-	case 23:
-		return "6"
-	case 22:
-		return "5.1"
-	case 21:
-		return "5.0"
-	case 20:
-		return "4.4W o_O"
-	case 19:
-		return "4.4"
-	case 18:
-		return "4.3"
-	case 17:
-		return "4.2"
-	case 16:
-		return "4.1"
-	case 15:
-		return "4.0.4"
-	case 14:
-		return "4.0"
-	default:
-		return fmt.Sprintf("Unknown (%d)", sdkInt)
-	}
-}
-
 type AndroidAppObserver struct {
 	scheduler *scheduler.Scheduler
 }
@@ -208,6 +82,8 @@ func (a AndroidAppObserver) requestReviews(app handlers.Application, respChannel
 		}
 		utils.LogError(err)
 	})
+
+	checkAlerts(app, respChannel)
 }
 
 func (a AndroidAppObserver) handlePage(reviewService *androidpublisher.ReviewsService,
@@ -268,15 +144,21 @@ func (a AndroidAppObserver) handleSingleComment(app handlers.Application,
 	respChannel chan tgbotapi.Chattable) {
 	lastModified := time.Unix(c.LastModified.Seconds, c.LastModified.Nanos)
 
-	var deviceName string
+	var deviceName, manufacturer string
+	if c.DeviceMetadata != nil {
+		manufacturer = c.DeviceMetadata.Manufacturer
+	}
 	if c.DeviceMetadata != nil && len(c.DeviceMetadata.ProductName) != 0 {
 		deviceName = c.DeviceMetadata.ProductName
 	} else {
 		deviceName = c.Device
 	}
-	review := userReview{
+	review := handlers.UserReview{
+		AppId:          app.ID,
+		ReviewId:       r.ReviewId,
 		UserName:       r.AuthorName,
 		Device:         deviceName,
+		Manufacturer:   manufacturer,
 		SdkInt:         int(c.AndroidOsVersion),
 		Text:           c.Text,
 		Time:           lastModified,
@@ -284,10 +166,22 @@ func (a AndroidAppObserver) handleSingleComment(app handlers.Application,
 		AppVersion:     c.AppVersionName,
 		AppBuildNumber: c.AppVersionCode,
 		AppName:        app.GetName(),
+		OriginalText:   c.OriginalText,
+		Language:       c.ReviewerLanguage,
+		Url:            fmt.Sprintf("https://play.google.com/store/apps/details?id=%s&reviewId=%s", app.PackageName, r.ReviewId),
+	}
+	for _, comment := range r.Comments {
+		if reply := comment.DeveloperComment; reply != nil {
+			review.ReplyText = reply.Text
+			if reply.LastModified != nil {
+				replyTime := time.Unix(reply.LastModified.Seconds, reply.LastModified.Nanos)
+				review.ReplyTime = &replyTime
+			}
+		}
 	}
+	review = saveReview(review)
 
-	log.Printf("[Android] Sending message to %d", app.ChatId)
-	respChannel <- tgbotapi.NewMessage(app.ChatId, review.format())
+	notifier.Notify(app, review, respChannel)
 }
 
 func (a AndroidAppObserver) rescheduleAndroid(apps []handlers.Application, respChannel chan tgbotapi.Chattable) {
@@ -1,151 +1,152 @@
 package handlers
 
 import (
-	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
 	"google-play-review-bot/utils"
 	"io/ioutil"
 	"log"
+	"strings"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	tgbotapi "gopkg.in/telegram-bot-api.v4"
 )
 
-type NewAppHandler struct {
+// NewAppFlow adds an app: the os is chosen first, unless the onboarding did it, then the package name or app id is asked,
+// android apps need a json key too. Admins of groups may add apps right there, the key is asked in the private chat.
+func NewAppFlow() *Flow {
+	return registerFlow(&Flow{
+		Id: "NewApp",
+		Start: func(ctx Context, c *Conversation) error {
+			if !ctx.IsChatAdmin() {
+				return i18n.Errorf("Only admins of this group can add apps")
+			}
+			c.Set("chat", ctx.ChatId())
+			return nil
+		},
+		Steps: []Step{
+			{
+				Name:     "os",
+				Waiting:  "os selection",
+				Callback: true,
+				Skip: func(c *Conversation) bool {
+					_, chosen := c.Data["os"]
+					return chosen
+				},
+				Prompt:  chooseOsPrompt,
+				Receive: receiveOs,
+			},
+			{
+				Name:    "packagename",
+				Waiting: "package name",
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					if c.String("os") == "ios" {
+						return tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Specify app id"))
+					}
+					return tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Specify package name"))
+				},
+				Receive: receivePackageName,
+			},
+			{
+				Name:    "key",
+				Waiting: "json key",
+				Private: true,
+				Skip: func(c *Conversation) bool {
+					return c.String("os") != "android"
+				},
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					return tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Please send json key"))
+				},
+				Receive: receiveKey,
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			if c.String("os") == "android" {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("File saved"))
+			} else {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Saved"))
+			}
+			var chatId int64
+			if c.Get("chat", &chatId) == nil && chatId != ctx.ChatId() {
+				ctx.Resp <- tgbotapi.NewMessage(chatId, ctx.T("%s is added, reviews will be posted here", c.String("packagename")))
+			}
+			ctx.AppChanges <- 1
+			ctx.createAppTopicIfForum(c.AppId(), chatId)
+		},
+		Rollback: func(store *datastore.Datastore, c *Conversation) {
+			var id primitive.ObjectID
+			if c.Get("app", &id) != nil {
+				return
+			}
+
+			_, err := store.DB().Collection(collections.APPS).DeleteOne(store.Context, bson.M{"_id": id})
+			utils.LogError(err)
+		},
+	})
 }
 
-var _ Handler = NewAppHandler{}
-
-func (NewAppHandler) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/newapp") {
-		return false
-	}
-
-	if !ctx.Update.Message.Chat.IsPrivate() {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Adding new app allowed only in private chats.")
-		return true
+func chooseOsPrompt(ctx Context, c *Conversation) tgbotapi.Chattable {
+	message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Choose your os"))
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		{tgbotapi.NewInlineKeyboardButtonData("Android", "android"),
+			tgbotapi.NewInlineKeyboardButtonData("iOS", "ios")},
 	}
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
 
-	if ctx.ChangeChatStateOrAnswerDefault(ChatStateWaitForOS) {
-		message := tgbotapi.NewMessage(ctx.ChatId(), "Choose your os")
-		rows := [][]tgbotapi.InlineKeyboardButton{
-			{tgbotapi.NewInlineKeyboardButtonData("Android", "android"),
-				tgbotapi.NewInlineKeyboardButtonData("iOS", "ios")},
-		}
-		message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
-
-		ctx.Resp <- message
-	}
-
-	return true
-}
-
-func (NewAppHandler) Name() string {
-	return "New App Handler"
-}
-
-type IosAndroidHandler struct {
+	return message
 }
 
-var _ Handler = IosAndroidHandler{}
-
-func (IosAndroidHandler) Handle(ctx Context) bool {
-	if ok, _ := ctx.EnsureChatState(ChatStateWaitForOS); !ok {
-		return false
-	}
-
+func receiveOs(ctx Context, c *Conversation) (interface{}, error) {
 	os := ctx.Update.CallbackQuery.Data
-	if os == "android" {
-		ctx.ChangeChatStateWithNextState(ChatStateWaitForPackageName, ChatStateWaitForKey)
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Specify package name")
-	} else if os == "ios" {
-		ctx.ChangeChatState(ChatStateWaitForPackageName)
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Specify app id")
-	} else {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Invalid OS")
-		return true
+	if os != "android" && os != "ios" {
+		return nil, i18n.Errorf("Invalid OS")
 	}
 
-	ctx.SaveOS(os)
-
-	return true
+	return os, nil
 }
 
-func (IosAndroidHandler) Name() string {
-	return "IosAndroidHandler"
-}
-
-type PackageNameReceiver struct {
-}
-
-var _ Handler = PackageNameReceiver{}
-
-func (PackageNameReceiver) Handle(ctx Context) bool {
-	stateOk, chat := ctx.EnsureChatState(ChatStateWaitForPackageName)
-	if !stateOk {
-		return false
+// receivePackageName creates the app, it is kept for another attempt when the package name is taken.
+func receivePackageName(ctx Context, c *Conversation) (interface{}, error) {
+	packageName := strings.TrimSpace(ctx.Update.Message.Text)
+	if packageName == "" {
+		return nil, i18n.Errorf("Please provide %s", i18n.Text("package name"))
 	}
 
-	packageName := ctx.Update.Message.Text
-	err := ctx.SavePackageName(packageName)
+	if _, created := c.Data["app"]; !created {
+		c.Set("app", ctx.SaveOS(c.String("os")))
+	}
 
+	err := ctx.SavePackageName(c.AppId(), packageName)
 	if err != nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), fmt.Sprintf(
+		log.Printf("SavePackageName: %s", err)
+		return nil, i18n.Errorf(
 			"You already have app with packageName/appId = %s in this chat.\n Please provide another packageName or /reset",
-			packageName))
-	} else {
-		nextState := int(chat.CustomData.(int32))
-		if nextState != ChatStateNone {
-			ctx.ChangeChatState(ChatStateWaitForKey)
-			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Please send json key")
-		} else {
-			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Saved")
-			err = ctx.ChangeChatState(ChatStateNone)
-			utils.PanicOnError(err)
-
-			ctx.AppChanges <- 1
-		}
+			packageName)
 	}
 
-	return true
+	return packageName, nil
 }
 
-func (PackageNameReceiver) Name() string {
-	return "PackageNameReceiver"
-}
-
-type KeyReceiver struct {
-}
-
-var _ Handler = KeyReceiver{}
-
-func (KeyReceiver) Handle(ctx Context) bool {
-	if ok, _ := ctx.EnsureChatState(ChatStateWaitForKey); !ok {
-		return false
+func receiveKey(ctx Context, c *Conversation) (interface{}, error) {
+	if ctx.Update.Message.Document == nil {
+		return nil, i18n.Errorf("Please send json key")
 	}
 
-	fileId := ctx.Update.Message.Document.FileID
-	reader, err := ctx.downloadFile(fileId)
+	reader, err := ctx.downloadFile(ctx.Update.Message.Document.FileID)
 	if err != nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Error downloading file: "+err.Error())
-		return true
+		return nil, i18n.Errorf("Error downloading file: %s", err)
 	}
+	defer reader.Close()
 
 	buf, err := ioutil.ReadAll(reader)
 	log.Printf("Read %d bytes", len(buf))
 	if err != nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Error downloading file: "+err.Error())
-		return true
+		return nil, i18n.Errorf("Error downloading file: %s", err)
 	}
 
-	ctx.SetKeyFile(buf)
-	err = ctx.ChangeChatState(ChatStateNone)
-	utils.PanicOnError(err)
-
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "File saved")
-	ctx.AppChanges <- 1
-
-	return true
-}
+	ctx.SetKeyFile(c.AppId(), buf)
 
-func (KeyReceiver) Name() string {
-	return "KeyReceiver"
+	return nil, nil
 }
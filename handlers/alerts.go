@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const AcknowledgeAlertPrefix = "ackalert_"
+
+func ChangeAlertsFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeAlerts",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			{
+				Name:    "alerts",
+				Waiting: "alert settings: <min count> <ratio> <window hours> <cooldown hours>, or off",
+				Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+					return parseAlertSettings(ctx.Update.Message.Text)
+				},
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			var settings AlertSettings
+			utils.PanicOnError(c.Get("alerts", &settings))
+			ctx.updateApp(c.AppId(), bson.M{"alerts": settings})
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Alert settings changed"))
+		},
+	})
+}
+
+func parseAlertSettings(text string) (AlertSettings, error) {
+	text = strings.TrimSpace(text)
+	if strings.EqualFold(text, "off") {
+		settings := DefaultAlertSettings
+		settings.Disabled = true
+		return settings, nil
+	}
+
+	usage := i18n.Errorf("Expected: <min count> <ratio> <window hours> <cooldown hours>, e.g. \"%d %g %d %d\", or off",
+		DefaultAlertSettings.MinCount,
+		DefaultAlertSettings.Ratio,
+		DefaultAlertSettings.WindowHours,
+		DefaultAlertSettings.CooldownHours,
+	)
+
+	fields := strings.Fields(text)
+	if len(fields) != 4 {
+		return AlertSettings{}, usage
+	}
+
+	var settings AlertSettings
+	var err error
+	if settings.MinCount, err = strconv.Atoi(fields[0]); err != nil || settings.MinCount < 1 {
+		return AlertSettings{}, usage
+	}
+	if settings.Ratio, err = strconv.ParseFloat(fields[1], 64); err != nil || settings.Ratio <= 0 {
+		return AlertSettings{}, usage
+	}
+	if settings.WindowHours, err = strconv.Atoi(fields[2]); err != nil || settings.WindowHours < 1 {
+		return AlertSettings{}, usage
+	}
+	if settings.CooldownHours, err = strconv.Atoi(fields[3]); err != nil || settings.CooldownHours < 0 {
+		return AlertSettings{}, usage
+	}
+
+	return settings, nil
+}
+
+type AcknowledgeAlert struct {
+	Handler
+}
+
+func (AcknowledgeAlert) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, AcknowledgeAlertPrefix) {
+		return false
+	}
+
+	alertId, err := primitive.ObjectIDFromHex(strings.TrimPrefix(query.Data, AcknowledgeAlertPrefix))
+	utils.PanicOnError(err)
+
+	by := query.From.UserName
+	if by == "" {
+		by = query.From.FirstName
+	} else {
+		by = "@" + by
+	}
+	now := time.Now()
+
+	res, err := ctx.Store.DB().Collection(collections.ALERTS).UpdateOne(ctx.Store.Context, bson.M{
+		"_id": alertId,
+		"acknowledgedby": bson.M{
+			"$exists": false,
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"acknowledgedby": by,
+			"acknowledgedat": now,
+		},
+	})
+	utils.PanicOnError(err)
+
+	// somebody pressed the button before, the message already tells who
+	if res.ModifiedCount == 0 {
+		_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Already acknowledged")))
+		utils.LogError(err)
+		return true
+	}
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Acknowledged")))
+	utils.LogError(err)
+
+	if query.Message != nil {
+		ctx.Resp <- tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+			query.Message.Text+"\n\n"+ctx.T("✅ Acknowledged by %s", by))
+	}
+
+	return true
+}
+
+func (AcknowledgeAlert) Name() string {
+	return "AcknowledgeAlert"
+}
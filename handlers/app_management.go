@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"fmt"
 	"google-play-review-bot/collections"
 	"google-play-review-bot/utils"
 	"log"
@@ -19,10 +18,6 @@ type AppList struct {
 }
 
 func (AppList) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/apps") {
-		return false
-	}
-
 	var apps []struct{ PackageName string }
 	c, err := ctx.Store.DB().Collection(collections.APPS).Find(ctx.Store.Context, bson.M{
 		"userid": ctx.UserId(),
@@ -35,9 +30,9 @@ func (AppList) Handle(ctx Context) bool {
 
 	var resp string
 	if len(apps) == 0 {
-		resp = "You have no configured apps yet. /newapp ?"
+		resp = ctx.T("You have no configured apps yet. /newapp ?")
 	} else {
-		resp = "You have next apps:\n"
+		resp = ctx.T("You have next apps:") + "\n"
 		for _, v := range apps {
 			resp = resp + v.PackageName + "\n"
 		}
@@ -52,51 +47,42 @@ func (AppList) Name() string {
 	return "AppList"
 }
 
-type ChangeLanguage struct {
-	Handler
-}
-
-func (ChangeLanguage) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/changelanguage") {
-		return false
-	}
-
-	chattable := makeAppChooser(ctx)
-	if chattable == nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "No apps to change")
-		return true
-	}
-
-	if !ctx.ChangeChatStateWithNextStateOrAnswerDefault(ChatStateWaitForApp, ChatStateWaitForLanguage) {
-		return false
-	}
-
-	if chattable != nil {
-		ctx.Resp <- *chattable
-	}
-
-	return true
-}
+func ChangeLanguageFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeLanguage",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			textStep("language", "language code"),
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			ctx.updateAppRefetchingReviews(c.AppId(), bson.M{"translatelanguage": c.String("language")})
 
-func (ChangeLanguage) Name() string {
-	return "ChangeLanguage"
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Language changed"))
+			ctx.AppChanges <- 1
+		},
+	})
 }
 
-func makeAppChooser(ctx Context) *tgbotapi.Chattable {
-	var apps []Application
-	c, err := ctx.Store.DB().Collection(collections.APPS).Find(ctx.Store.Context, bson.M{
-		"userid": ctx.UserId(),
+// updateAppRefetchingReviews changes the app and makes observers fetch its reviews again, e.g. to translate them.
+func (ctx Context) updateAppRefetchingReviews(appId primitive.ObjectID, update bson.M) {
+	update["lastreview"] = time.Time{}
+	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": appId}, bson.M{
+		"$set": update,
+		"$unset": bson.M{
+			"lastreviewid": 1,
+		},
 	})
 	utils.PanicOnError(err)
+}
 
-	err = c.All(ctx.Store.Context, &apps)
-	utils.PanicOnError(err)
-
+func makeAppChooser(ctx Context) *tgbotapi.Chattable {
+	apps := ctx.UserApps()
 	if len(apps) == 0 {
 		return nil
 	}
 
-	var message = tgbotapi.NewMessage(ctx.ChatId(), "Please choose app")
+	var message = tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Please choose app"))
 	var rows [][]tgbotapi.InlineKeyboardButton
 	for _, app := range apps {
 		rows = append(rows, []tgbotapi.InlineKeyboardButton{
@@ -111,221 +97,52 @@ func makeAppChooser(ctx Context) *tgbotapi.Chattable {
 	return &chattable
 }
 
-type ChangeLanguageReceiver struct {
-	Handler
-}
-
-func (ChangeLanguageReceiver) Handle(ctx Context) bool {
-	stateOk, chat := ctx.EnsureChatState(ChatStateWaitForLanguage)
-	if !stateOk {
-		return false
-	}
-
-	language := ctx.Update.Message.Text
-
-	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": chat.CustomData}, bson.M{
-		"$set": bson.M{
-			"translatelanguage": language,
-			"lastreview":        time.Time{},
-		},
-		"$unset": bson.M{
-			"lastreviewid": 1,
+func ChangeAppNameFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeAppName",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			textStep("name", "application name"),
 		},
-	})
-	utils.PanicOnError(err)
-
-	err = ctx.ChangeChatStateWithNextState(ChatStateNone, ChatStateNone)
-	utils.PanicOnError(err)
-
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Language changed")
-	ctx.AppChanges <- 1
+		Finish: func(ctx Context, c *Conversation) {
+			ctx.updateAppRefetchingReviews(c.AppId(), bson.M{"name": c.String("name")})
+			ctx.renameAppTopic(c.AppId())
 
-	return true
-}
-
-func (ChangeLanguageReceiver) Name() string {
-	return "ChangeLanguageReceiver"
-}
-
-type ChooseAppReceiver struct {
-	Handler
-	BotUserName string
-}
-
-func (c ChooseAppReceiver) Handle(ctx Context) bool {
-	stateOk, chat := ctx.EnsureChatState(ChatStateWaitForApp)
-	if !stateOk || ctx.Update.CallbackQuery == nil {
-		return false
-	}
-
-	id := ctx.Update.CallbackQuery.Data
-	nextState := int(chat.CustomData.(int32))
-
-	if nextState >= 0 {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), fmt.Sprintf("Please provide %s", ChatStateToWaitingString(nextState)))
-	}
-
-	var stateCall int
-	if nextState < 0 {
-		stateCall = nextState
-		nextState = ChatStateNone
-	}
-
-	objectID, err := primitive.ObjectIDFromHex(id)
-	utils.PanicOnError(err)
-
-	_, err = ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
-		"chatid": ctx.ChatId(),
-		"userid": ctx.UserId(),
-	}, bson.M{
-		"$set": bson.M{
-			"customdata": objectID,
-			"state":      nextState,
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("App name changed"))
+			ctx.AppChanges <- 1
 		},
 	})
-	utils.PanicOnError(err)
-
-	if stateCall != 0 {
-		ChatStateCall(stateCall, c.BotUserName, ctx)
-	}
-
-	return true
-}
-
-func (ChooseAppReceiver) Name() string {
-	return "ChooseAppReceiver"
-}
-
-type ChangeAppName struct {
-	Handler
 }
 
-func (ChangeAppName) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/changeappname") {
-		return false
-	}
-
-	chattable := makeAppChooser(ctx)
-	if chattable == nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "No apps to change")
-		return true
-	}
-
-	if !ctx.ChangeChatStateWithNextStateOrAnswerDefault(ChatStateWaitForApp, ChatStateWaitForAppName) {
-		return false
-	}
-
-	if chattable != nil {
-		ctx.Resp <- *chattable
-	}
-
-	return true
-}
-
-func (ChangeAppName) Name() string {
-	return "ChangeAppName"
-}
-
-type ChangeAppNameReceiver struct {
-	Handler
-}
-
-func (ChangeAppNameReceiver) Handle(ctx Context) bool {
-	stateOk, chat := ctx.EnsureChatState(ChatStateWaitForAppName)
-	if !stateOk {
-		return false
-	}
-
-	name := ctx.Update.Message.Text
-
-	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": chat.CustomData}, bson.M{
-		"$set": bson.M{
-			"name":       name,
-			"lastreview": time.Time{},
+// ChangeGroupFlow unbinds the app from its chat and sends a link to bind it to a group, or back to the private chat.
+func ChangeGroupFlow(botUserName string) *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeGroup",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
 		},
-		"$unset": bson.M{
-			"lastreviewid": 1,
-		},
-	})
-	utils.PanicOnError(err)
-
-	ctx.ChangeChatStateWithNextState(ChatStateNone, ChatStateNone)
-
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "App name changed")
-	ctx.AppChanges <- 1
-
-	return true
-}
-
-func (ChangeAppNameReceiver) Name() string {
-	return "ChangeAppNameReceiver"
-}
-
-type ChangeGroup struct {
-	Handler
-}
-
-func (ChangeGroup) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/changegroup") {
-		return false
-	}
-
-	chattable := makeAppChooser(ctx)
-	if chattable == nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "No apps to change")
-		return true
-	}
-
-	if !ctx.ChangeChatStateWithNextStateOrAnswerDefault(ChatStateWaitForApp, ChatStateCallChangeGroupReceiver) {
-		return false
-	}
-
-	if chattable != nil {
-		ctx.Resp <- *chattable
-	}
-
-	return true
-}
-
-func (ChangeGroup) Name() string {
-	return "ChangeGroup"
-}
-
-type ChangeGroupReceiver struct {
-	Handler
-	BotUserName string
-}
-
-func (c ChangeGroupReceiver) Handle(ctx Context) bool {
-	log.Printf("ChangeGroupReceiver")
-	var chat Chat
-	chatSelector := bson.M{
-		"chatid": ctx.ChatId(),
-		"userid": ctx.UserId(),
-	}
-	err := ctx.Store.DB().Collection(collections.CHAT).FindOne(ctx.Store.Context, chatSelector).Decode(&chat)
-	utils.PanicOnError(err)
-
-	id := chat.CustomData.(primitive.ObjectID)
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Great, check private chat for further instructions.")
-	ctx.Resp <- tgbotapi.NewMessage(int64(ctx.UserId()),
-		"Use next lint to add me to desired group: https://telegram.me/"+c.BotUserName+"?startgroup="+id.Hex()+"\n Or leave it here: /private_"+id.Hex())
-
-	_, err = ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": id}, bson.M{
-		"$unset": bson.M{
-			"chatid": 1,
+		Finish: func(ctx Context, c *Conversation) {
+			id := c.AppId()
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Great, check private chat for further instructions."))
+			ctx.Resp <- tgbotapi.NewMessage(int64(ctx.UserId()), ctx.T(
+				"Use next lint to add me to desired group: %s\n Or leave it here: %s",
+				"https://telegram.me/"+botUserName+"?startgroup="+id.Hex(),
+				"/private_"+id.Hex()))
+
+			_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": id}, bson.M{
+				"$unset": bson.M{
+					"chatid":          1,
+					"messagethreadid": 1,
+					"unreachable":     1,
+				},
+			})
+			utils.PanicOnError(err)
+
+			ctx.AppChanges <- 1
 		},
 	})
-	utils.PanicOnError(err)
-	ctx.ChangeChatState(ChatStateNone)
-
-	ctx.AppChanges <- 1
-
-	return true
-}
-
-func (ChangeGroupReceiver) Name() string {
-	return "ChangeGroup"
 }
 
 type ChangeGroupPrivateReceiver struct {
@@ -333,15 +150,18 @@ type ChangeGroupPrivateReceiver struct {
 }
 
 func (ChangeGroupPrivateReceiver) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/private") {
-		return false
+	// in groups the command may be addressed as /private_<id>@bot
+	command := strings.SplitN(strings.Fields(ctx.Update.Message.Text)[0], "@", 2)[0]
+	appId, err := primitive.ObjectIDFromHex(strings.TrimPrefix(command, "/private_"))
+	if err == nil {
+		_, err = ctx.findUserApp(appId)
+	}
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Usage: /private_<id>, use the link sent by /changegroup"))
+		return true
 	}
 
-	appId := strings.Split(ctx.Update.Message.Text, "_")[1]
-	appObjectID, err := primitive.ObjectIDFromHex(appId)
-	utils.PanicOnError(err)
-
-	ctx.BindAppToChatId(appObjectID, int64(ctx.UserId()))
+	ctx.BindAppToChatId(appId, int64(ctx.UserId()))
 
 	return true
 }
@@ -350,67 +170,19 @@ func (ChangeGroupPrivateReceiver) Name() string {
 	return "ChangeGroupPrivateReceiver"
 }
 
-type ChangeAppStore struct {
-	Handler
-}
-
-func (ChangeAppStore) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/changeappstore") {
-		return false
-	}
-
-	chattable := makeAppChooser(ctx)
-	if chattable == nil {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "No apps to change")
-		return true
-	}
-
-	if !ctx.ChangeChatStateWithNextStateOrAnswerDefault(ChatStateWaitForApp, ChangeAppStoreWaitForCode) {
-		return false
-	}
-
-	if chattable != nil {
-		ctx.Resp <- *chattable
-	}
-
-	return true
-}
-
-func (ChangeAppStore) Name() string {
-	return "ChangeAppStore"
-}
-
-type ChangeAppStoreReceiver struct {
-	Handler
-}
-
-func (ChangeAppStoreReceiver) Handle(ctx Context) bool {
-	stateOk, chat := ctx.EnsureChatState(ChangeAppStoreWaitForCode)
-	if !stateOk {
-		return false
-	}
-
-	code := ctx.Update.Message.Text
-
-	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": chat.CustomData}, bson.M{
-		"$set": bson.M{
-			"appStoreCountryCode": code,
-			"lastreview":          time.Time{},
+func ChangeAppStoreFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeAppStore",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			textStep("code", "AppStore code"),
 		},
-		"$unset": bson.M{
-			"lastreviewid": 1,
+		Finish: func(ctx Context, c *Conversation) {
+			ctx.updateAppRefetchingReviews(c.AppId(), bson.M{"appStoreCountryCode": c.String("code")})
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("App store code changed"))
+			ctx.AppChanges <- 1
 		},
 	})
-	utils.PanicOnError(err)
-
-	ctx.ChangeChatStateWithNextState(ChatStateNone, ChatStateNone)
-
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "App store code changed")
-	ctx.AppChanges <- 1
-
-	return true
-}
-
-func (ChangeAppStoreReceiver) Name() string {
-	return "ChangeAppNameReceiver"
 }
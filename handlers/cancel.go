@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Cancel stops whatever the bot is waiting for, rolling back what the conversation saved so far.
+type Cancel struct {
+	Handler
+}
+
+func (Cancel) Handle(ctx Context) bool {
+	if ctx.CancelConversation() {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Cancelled"))
+	} else {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Nothing to cancel"))
+	}
+
+	return true
+}
+
+func (Cancel) Name() string {
+	return "Cancel"
+}
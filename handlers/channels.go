@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// ChangeChannelFlow binds the app to a channel given by a forwarded post or by its @username,
+// the bot has to be an admin of the channel allowed to post messages.
+func ChangeChannelFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeChannel",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			{
+				Name:    "channel",
+				Waiting: "channel",
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					return tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+						"Forward any post from the channel here or send its @username. I have to be an admin of the channel with the right to post messages"))
+				},
+				Receive: receiveChannel,
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			var channelId int64
+			utils.PanicOnError(c.Get("channel", &channelId))
+			ctx.bindAppToChannel(c.AppId(), channelId)
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Reviews will be posted to %s", c.String("title")))
+			ctx.AppChanges <- 1
+		},
+	})
+}
+
+func receiveChannel(ctx Context, c *Conversation) (interface{}, error) {
+	message := ctx.Update.Message
+
+	var chat tgbotapi.Chat
+	if message.ForwardFromChat != nil {
+		chat = *message.ForwardFromChat
+	} else {
+		username := strings.TrimSpace(message.Text)
+		if !strings.HasPrefix(username, "@") {
+			return nil, i18n.Errorf("Forward a post from the channel or send its @username")
+		}
+
+		var err error
+		chat, err = ctx.Bot.GetChat(tgbotapi.ChatConfig{SuperGroupUsername: username})
+		if err != nil {
+			log.Printf("[receiveChannel] GetChat %s: %s", username, err)
+			return nil, i18n.Errorf("Can't find %s, check the username or forward a post from the channel", username)
+		}
+	}
+
+	if !chat.IsChannel() {
+		return nil, i18n.Errorf("%s is not a channel", chat.Title)
+	}
+	if err := ctx.verifyChannel(chat.ID); err != nil {
+		return nil, err
+	}
+
+	c.Set("title", chat.Title)
+
+	return chat.ID, nil
+}
+
+// verifyChannel checks that the bot may post to the channel and that the user is one of its admins.
+func (ctx Context) verifyChannel(channelId int64) error {
+	bot, err := ctx.Bot.GetChatMember(tgbotapi.ChatConfigWithUser{ChatID: channelId, UserID: ctx.Bot.Self.ID})
+	if err != nil || !bot.IsAdministrator() || !bot.CanPostMessages {
+		return i18n.Errorf("I can't post to the channel, make me an admin with the right to post messages and try again")
+	}
+
+	user, err := ctx.Bot.GetChatMember(tgbotapi.ChatConfigWithUser{ChatID: channelId, UserID: ctx.UserId()})
+	if err != nil || !(user.IsCreator() || user.IsAdministrator()) {
+		return i18n.Errorf("Only admins of the channel can post reviews to it")
+	}
+
+	return nil
+}
+
+func (ctx Context) bindAppToChannel(appId primitive.ObjectID, channelId int64) {
+	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
+		"_id": appId,
+	}, bson.M{
+		"$set": bson.M{
+			"chatid": channelId,
+		},
+		"$unset": bson.M{
+			"lastreview":      1,
+			"lastreviewid":    1,
+			"messagethreadid": 1,
+			"unreachable":     1,
+		},
+	})
+	utils.PanicOnError(err)
+	log.Printf("[bindAppToChannel] appId: %v, chatId: %v", appId, channelId)
+}
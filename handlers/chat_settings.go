@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const defaultDateFormat = "2006-01-02 15:04"
+
+// dateFormats are offered to choose from, the index is used as callback data.
+var dateFormats = []string{
+	defaultDateFormat,
+	"02.01.2006 15:04",
+	"01/02/2006 3:04 PM",
+	"02 Jan 2006 15:04",
+	"Mon, 02 Jan 2006 15:04 MST",
+}
+
+var utcOffsetRegexp = regexp.MustCompile(`^(?i:UTC|GMT)?\s*([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+// ChatSettings are shared by everybody in a chat, unlike Chat which is kept per user.
+type ChatSettings struct {
+	ChatId     int64
+	Timezone   string `bson:",omitempty"`
+	DateFormat string `bson:",omitempty"`
+	// Language of the bot's own messages, set in private chats where ChatId is the id of the user
+	Language string `bson:",omitempty"`
+}
+
+func LoadChatSettings(store *datastore.Datastore, chatId int64) ChatSettings {
+	settings := ChatSettings{ChatId: chatId}
+	err := store.DB().Collection(collections.CHAT_SETTINGS).FindOne(store.Context, bson.M{"chatid": chatId}).Decode(&settings)
+	if err != nil && err != mongo.ErrNoDocuments {
+		utils.LogError(err)
+	}
+
+	return settings
+}
+
+func (ctx Context) ChatSettings() ChatSettings {
+	return LoadChatSettings(ctx.Store, ctx.ChatId())
+}
+
+func (ctx Context) saveChatSettings(update bson.M) {
+	_, err := ctx.Store.DB().Collection(collections.CHAT_SETTINGS).UpdateOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+	}, bson.M{
+		"$set": update,
+	}, options.Update().SetUpsert(true))
+	utils.PanicOnError(err)
+}
+
+// Location returns the timezone of the chat, the server one is used until it is set.
+func (s ChatSettings) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.Local
+	}
+
+	location, err := parseTimezone(s.Timezone)
+	if err != nil {
+		utils.LogError(err)
+		return time.Local
+	}
+
+	return location
+}
+
+func (s ChatSettings) Layout() string {
+	if s.DateFormat == "" {
+		return defaultDateFormat
+	}
+
+	return s.DateFormat
+}
+
+func (s ChatSettings) FormatTime(t time.Time) string {
+	return t.In(s.Location()).Format(s.Layout())
+}
+
+// parseTimezone accepts IANA names like Europe/Berlin and fixed offsets like UTC+3 or -05:30.
+func parseTimezone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if match := utcOffsetRegexp.FindStringSubmatch(name); match != nil {
+		hours, _ := strconv.Atoi(match[2])
+		minutes, _ := strconv.Atoi(match[3])
+		if hours > 14 || minutes > 59 {
+			return nil, i18n.Errorf("Invalid UTC offset %s", name)
+		}
+		offset := hours*3600 + minutes*60
+		if match[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(formatUtcOffset(offset), offset), nil
+	}
+
+	if name == "" || strings.EqualFold(name, "local") {
+		return nil, i18n.Errorf("Unknown timezone %q", name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, i18n.Errorf("Unknown timezone %q, expected a name like Europe/Berlin or an offset like UTC+3", name)
+	}
+
+	return location, nil
+}
+
+func formatUtcOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	return fmt.Sprintf("UTC%s%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+type zoneCity struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+}
+
+// zoneCityDistance is how far the nearest zone city may be, farther away, e.g. at sea, the offset follows the longitude.
+const zoneCityDistance = 2000
+
+// timezoneFromLocation picks the timezone of the nearest zone city, which may be wrong close to borders.
+func timezoneFromLocation(location *tgbotapi.Location) string {
+	nearest, distance := "", math.Inf(1)
+	for _, city := range zoneCities {
+		d := distanceKm(location.Latitude, location.Longitude, city.Latitude, city.Longitude)
+		if d >= distance {
+			continue
+		}
+		// the tz database of the server may lack newer zones
+		if _, err := time.LoadLocation(city.Name); err == nil {
+			nearest, distance = city.Name, d
+		}
+	}
+
+	if distance > zoneCityDistance {
+		hours := int(math.Round(location.Longitude / 15))
+		return formatUtcOffset(hours * 3600)
+	}
+
+	return nearest
+}
+
+// distanceKm is the great-circle distance between two points.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// commonTimezones are offered as keyboard buttons, any other IANA name or UTC offset may be typed.
+var commonTimezones = []string{
+	"UTC", "Europe/London", "Europe/Berlin",
+	"Europe/Istanbul", "Europe/Moscow", "Asia/Dubai",
+	"Asia/Kolkata", "Asia/Singapore", "Asia/Tokyo",
+	"Australia/Sydney", "America/Sao_Paulo", "America/New_York",
+	"America/Chicago", "America/Denver", "America/Los_Angeles",
+}
+
+const timezoneWaiting = "timezone like Europe/Berlin or UTC+3, or share location"
+
+// ChangeTimezoneFlow sets the timezone and then the date format of the chat, examples are shown in the new timezone.
+func ChangeTimezoneFlow() *Flow {
+	return registerFlow(&Flow{
+		Id: "ChangeTimezone",
+		Steps: []Step{
+			{
+				Name:    "timezone",
+				Waiting: timezoneWaiting,
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					current := ctx.ChatSettings().Timezone
+					if current == "" {
+						current = ctx.T("server default, %s", time.Now().Format("MST"))
+					}
+
+					message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Current timezone: %s\nPlease provide %s",
+						current, ctx.T(timezoneWaiting)))
+					var rows [][]tgbotapi.KeyboardButton
+					// telegram allows requesting location in private chats only
+					if ctx.IsPrivateChat() {
+						rows = append(rows, tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButtonLocation(ctx.T("Share location"))))
+					}
+					for i, name := range commonTimezones {
+						if i%3 == 0 {
+							rows = append(rows, nil)
+						}
+						rows[len(rows)-1] = append(rows[len(rows)-1], tgbotapi.NewKeyboardButton(name))
+					}
+					keyboard := tgbotapi.NewReplyKeyboard(rows...)
+					keyboard.OneTimeKeyboard = true
+					// in groups only the user who ran /timezone sees the keyboard
+					keyboard.Selective = true
+					message.ReplyMarkup = keyboard
+					if ctx.Update.Message != nil {
+						message.ReplyToMessageID = ctx.Update.Message.MessageID
+					}
+					return message
+				},
+				Receive: receiveTimezone,
+			},
+			{
+				Name:     "dateformat",
+				Waiting:  "date format",
+				Callback: true,
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					location, err := parseTimezone(c.String("timezone"))
+					utils.PanicOnError(err)
+
+					var rows [][]tgbotapi.InlineKeyboardButton
+					for i, layout := range dateFormats {
+						example := time.Now().In(location).Format(layout)
+						rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(example, strconv.Itoa(i))))
+					}
+					message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Choose date format"))
+					message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+					return message
+				},
+				Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+					i, err := strconv.Atoi(ctx.Update.CallbackQuery.Data)
+					if err != nil || i < 0 || i >= len(dateFormats) {
+						return nil, i18n.Errorf("Invalid date format")
+					}
+					return dateFormats[i], nil
+				},
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			ctx.saveChatSettings(bson.M{
+				"timezone":   c.String("timezone"),
+				"dateformat": c.String("dateformat"),
+			})
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Reviews will be dated like %s", ctx.ChatSettings().FormatTime(time.Now())))
+		},
+	})
+}
+
+func receiveTimezone(ctx Context, c *Conversation) (interface{}, error) {
+	var timezone string
+	if location := ctx.Update.Message.Location; location != nil {
+		timezone = timezoneFromLocation(location)
+	} else {
+		location, err := parseTimezone(ctx.Update.Message.Text)
+		if err != nil {
+			return nil, err
+		}
+		timezone = location.String()
+	}
+
+	confirmation := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Timezone set to %s", timezone))
+	confirmation.ReplyMarkup = tgbotapi.NewRemoveKeyboard(false)
+	ctx.Resp <- confirmation
+
+	return timezone, nil
+}
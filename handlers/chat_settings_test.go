@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"testing"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+func TestTimezoneFromLocation(t *testing.T) {
+	tests := []struct {
+		latitude  float64
+		longitude float64
+		want      string
+	}{
+		// Potsdam
+		{52.40, 13.06, "Europe/Berlin"},
+		// Brooklyn
+		{40.65, -73.95, "America/New_York"},
+		// Yokohama
+		{35.44, 139.64, "Asia/Tokyo"},
+		// Denver suburbs, not the nearest UTC offset by longitude
+		{39.65, -104.98, "America/Denver"},
+	}
+
+	for _, test := range tests {
+		got := timezoneFromLocation(&tgbotapi.Location{Latitude: test.latitude, Longitude: test.longitude})
+		if got != test.want {
+			t.Errorf("timezoneFromLocation(%f, %f) = %s, want %s", test.latitude, test.longitude, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"net/url"
+	"strings"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+type CommandScope int
+
+const (
+	AllChats CommandScope = iota
+	PrivateChats
+	GroupChats
+)
+
+// Command wraps the handler of a slash command with what is shown in the telegram command menu and in /help.
+// The wrapped handler is only called for the command, a wrapped Flow is started by it and continues its conversation.
+type Command struct {
+	Handler
+	// Command is the name without the slash
+	Command string
+	// Description is english text, it is translated with i18n
+	Description string
+	Scope       CommandScope
+	// AdminOnly commands change apps or settings of the chat, in groups only admins may run them
+	AdminOnly bool
+	// Usage is shown in /help instead of the name, e.g. for commands with arguments
+	Usage string
+	// Hidden commands come from links and messages of the bot, they are listed in /help but not in the command menu
+	Hidden bool
+}
+
+func (c Command) Handle(ctx Context) bool {
+	flow, isFlow := c.Handler.(*Flow)
+	if !ctx.EnsureCommand("/" + c.Command) {
+		return isFlow && flow.Handle(ctx)
+	}
+
+	if c.AdminOnly && !ctx.ensureChatAdmin(c.Command) {
+		return true
+	}
+
+	if isFlow {
+		flow.StartWith(ctx, nil)
+		return true
+	}
+
+	return c.Handler.Handle(ctx)
+}
+
+// ensureChatAdmin tells users who aren't admins of the group that they can't use the command.
+func (ctx Context) ensureChatAdmin(command string) bool {
+	if ctx.IsChatAdmin() {
+		return true
+	}
+
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Only admins of this group can use /%s", command))
+	return false
+}
+
+func (c Command) availableIn(private bool) bool {
+	if c.Scope == AllChats {
+		return true
+	}
+
+	return (c.Scope == PrivateChats) == private
+}
+
+// commands are collected from the handler list by RegisterCommands.
+var commands []Command
+
+type botCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+type botCommandScope struct {
+	Type string `json:"type"`
+}
+
+// RegisterCommands keeps the commands of the handler list for /help and sets them as the command menu of the bot,
+// separately for private and group chats and for every language of the bot.
+func RegisterCommands(bot *tgbotapi.BotAPI, handlers []Handler) {
+	commands = nil
+	for _, h := range handlers {
+		if command, ok := h.(Command); ok {
+			commands = append(commands, command)
+		}
+	}
+
+	for _, private := range []bool{true, false} {
+		scope := botCommandScope{Type: "all_private_chats"}
+		if !private {
+			scope = botCommandScope{Type: "all_group_chats"}
+		}
+
+		// the default list, without language_code, is shown to users of languages the bot doesn't speak
+		for _, language := range append([]string{""}, i18n.Languages...) {
+			if language == i18n.Default {
+				continue
+			}
+			translateTo := language
+			if translateTo == "" {
+				translateTo = i18n.Default
+			}
+
+			var list []botCommand
+			for _, command := range commands {
+				if command.availableIn(private) && !command.Hidden {
+					list = append(list, botCommand{
+						Command:     command.Command,
+						Description: i18n.T(translateTo, command.Description),
+					})
+				}
+			}
+
+			err := setMyCommands(bot, list, scope, language)
+			if err != nil {
+				utils.LogError(fmt.Errorf("setMyCommands %s %s: %s", scope.Type, language, err))
+			}
+		}
+	}
+	log.Printf("Registered %d commands", len(commands))
+}
+
+// setMyCommands calls the method directly, the telegram library predates it.
+func setMyCommands(bot *tgbotapi.BotAPI, list []botCommand, scope botCommandScope, language string) error {
+	commandsJson, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	scopeJson, err := json.Marshal(scope)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("commands", string(commandsJson))
+	params.Set("scope", string(scopeJson))
+	if language != "" {
+		params.Set("language_code", language)
+	}
+
+	_, err = bot.MakeRequest("setMyCommands", params)
+
+	return err
+}
+
+// helpText lists the commands available in the chat the update came from.
+func helpText(ctx Context) string {
+	private := ctx.Update.Message == nil || ctx.Update.Message.Chat.IsPrivate()
+
+	lines := []string{ctx.T("Available commands:")}
+	for _, command := range commands {
+		if command.availableIn(private) {
+			usage := command.Usage
+			if usage == "" {
+				usage = command.Command
+			}
+			lines = append(lines, fmt.Sprintf("/%s - %s", usage, ctx.T(command.Description)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+type Help struct {
+	Handler
+}
+
+func (Help) Handle(ctx Context) bool {
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), helpText(ctx))
+
+	return true
+}
+
+func (Help) Name() string {
+	return "Help"
+}
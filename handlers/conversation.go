@@ -0,0 +1,507 @@
+package handlers
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// ConversationTimeout is how long the bot waits for an answer unless the flow sets its own Timeout.
+var ConversationTimeout = time.Hour
+
+// flows are registered by their constructors, so conversations found in the database can be rolled back.
+var flows = map[string]*Flow{}
+
+// Flow is a multi-step conversation declared as a list of steps, the engine keeps its progress in the chat document.
+type Flow struct {
+	// Id is stored in the chat document to find the flow a conversation belongs to
+	Id string
+	// Timeout is how long the bot waits for an answer, ConversationTimeout is used when zero
+	Timeout time.Duration
+	// Start may refuse to start the flow, the error is shown to the user, or put initial data into the conversation
+	Start  func(ctx Context, c *Conversation) error
+	Steps  []Step
+	Finish func(ctx Context, c *Conversation)
+	// Rollback undoes what the steps saved when the flow is cancelled or expires, it runs outside of handlers
+	Rollback func(store *datastore.Datastore, c *Conversation)
+}
+
+func registerFlow(f *Flow) *Flow {
+	flows[f.Id] = f
+
+	return f
+}
+
+// Step asks a single question, the answer returned by Receive is stored in the conversation under the name of the step.
+type Step struct {
+	Name string
+	// Waiting describes the expected answer, it is shown by "I'm waiting for" and by the default prompt
+	Waiting string
+	// Callback steps are answered with inline keyboard buttons, others with messages
+	Callback bool
+	// Private steps are asked in the private chat with the user, a conversation started in a group moves there
+	Private bool
+	// Skip tells whether the step isn't needed with the answers given so far
+	Skip func(c *Conversation) bool
+	// Prompt asks the question, "Please provide <Waiting>" is sent when it is nil
+	Prompt func(ctx Context, c *Conversation) tgbotapi.Chattable
+	// Receive validates the answer, on error the message is shown and the step waits for another answer
+	Receive func(ctx Context, c *Conversation) (interface{}, error)
+}
+
+// Conversation is the progress of a Flow in a chat, Data keeps answers as raw bson so they are decoded into typed values.
+type Conversation struct {
+	Flow    string
+	Step    string
+	Waiting string
+	Data    map[string]bson.RawValue `bson:",omitempty"`
+	Expires time.Time
+	// Language of the user, to tell about the timeout when there is no update to detect it from
+	Language string
+}
+
+func (c *Conversation) Set(key string, value interface{}) {
+	t, data, err := bson.MarshalValue(value)
+	utils.PanicOnError(err)
+
+	if c.Data == nil {
+		c.Data = map[string]bson.RawValue{}
+	}
+	c.Data[key] = bson.RawValue{Type: t, Value: data}
+}
+
+// Get decodes the value stored under the key into the value pointed to.
+func (c *Conversation) Get(key string, value interface{}) error {
+	raw, ok := c.Data[key]
+	if !ok {
+		return fmt.Errorf("no %s in conversation %s", key, c.Flow)
+	}
+
+	return raw.Unmarshal(value)
+}
+
+func (c *Conversation) String(key string) string {
+	var value string
+	utils.PanicOnError(c.Get(key, &value))
+
+	return value
+}
+
+// AppId returns the app chosen with chooseAppStep.
+func (c *Conversation) AppId() primitive.ObjectID {
+	var id primitive.ObjectID
+	utils.PanicOnError(c.Get("app", &id))
+
+	return id
+}
+
+func (c *Conversation) Active() bool {
+	return c != nil && c.Expires.After(time.Now())
+}
+
+var _ Handler = (*Flow)(nil)
+
+// Handle continues the conversation of the flow, the flow is started by the Command wrapping it.
+func (f *Flow) Handle(ctx Context) bool {
+	c := ctx.activeConversation()
+	if c == nil || c.Flow != f.Id {
+		return false
+	}
+
+	return f.receive(ctx, c)
+}
+
+func (f *Flow) Name() string {
+	return f.Id
+}
+
+// StartWith runs the flow with some answers known in advance, steps which check for them are skipped.
+func (f *Flow) StartWith(ctx Context, data bson.M) {
+	c := &Conversation{Flow: f.Id, Language: ctx.Language}
+	for key, value := range data {
+		c.Set(key, value)
+	}
+
+	f.start(ctx, c)
+}
+
+// StartForApp runs the flow for the app chosen in the settings menu, the menu is refreshed when the flow finishes.
+func (f *Flow) StartForApp(ctx Context, appId primitive.ObjectID, menuMessageId int) {
+	f.StartWith(ctx, bson.M{"app": appId, "menu": menuMessageId})
+}
+
+func (f *Flow) start(ctx Context, c *Conversation) {
+	if f.Start != nil {
+		if err := f.Start(ctx, c); err != nil {
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.Localize(err))
+			return
+		}
+	}
+
+	if previous := ctx.activeConversation(); previous != nil && previous.Flow == f.Id {
+		f.rollback(ctx.Store, previous)
+	}
+
+	if err := ctx.claimConversation(c); err != nil {
+		ctx.answerWaiting(ctx.loadChatState())
+		return
+	}
+
+	f.advance(ctx, c, 0)
+}
+
+// claimConversation puts the conversation into the chat, the same flow may be started over,
+// any other conversation has to be finished or cancelled first.
+func (ctx Context) claimConversation(c *Conversation) error {
+	_, err := ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+		"userid": ctx.UserId(),
+		"$or": bson.A{
+			bson.M{"conversation": bson.M{"$exists": false}},
+			bson.M{"conversation.expires": bson.M{"$lt": time.Now()}},
+			bson.M{"conversation.flow": c.Flow},
+		},
+	}, bson.M{
+		"$set": bson.M{
+			"conversation": c,
+		},
+	}, options.Update().SetUpsert(true))
+
+	return err
+}
+
+// moveToPrivateChat continues the conversation in the private chat with the user, the group is told to look there.
+func (f *Flow) moveToPrivateChat(ctx Context, c *Conversation, waiting string) (Context, bool) {
+	ctx.EndConversation()
+
+	private := ctx.privateContext()
+	if previous := private.activeConversation(); previous != nil && previous.Flow == f.Id {
+		f.rollback(ctx.Store, previous)
+	}
+	if err := private.claimConversation(c); err != nil {
+		f.rollback(ctx.Store, c)
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+			"I'm waiting for something else from you in our private chat, finish it or /cancel there and start over"))
+		return ctx, false
+	}
+
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+		"I'll ask for %s in our private chat, if there is no message from me open @%s and send /start",
+		ctx.T(waiting), ctx.Bot.Self.UserName))
+
+	return private, true
+}
+
+func (f *Flow) receive(ctx Context, c *Conversation) bool {
+	index := f.stepIndex(c.Step)
+	if index < 0 {
+		return false
+	}
+	step := f.Steps[index]
+
+	if step.Callback {
+		query := ctx.Update.CallbackQuery
+		if query == nil {
+			return false
+		}
+		_, err := ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+		utils.LogError(err)
+	} else if ctx.Update.Message == nil || strings.HasPrefix(ctx.Update.Message.Text, "/") {
+		// commands are left to their handlers, which tell what the bot is waiting for
+		return false
+	}
+
+	value, err := step.Receive(ctx, c)
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.Localize(err))
+		return true
+	}
+	if value != nil {
+		c.Set(step.Name, value)
+	}
+
+	f.advance(ctx, c, index+1)
+
+	return true
+}
+
+// advance asks the first step starting from the index which isn't skipped, or finishes the flow when none is left.
+func (f *Flow) advance(ctx Context, c *Conversation, from int) {
+	for _, step := range f.Steps[from:] {
+		if step.Skip != nil && step.Skip(c) {
+			continue
+		}
+		if step.Private && !ctx.IsPrivateChat() {
+			var moved bool
+			if ctx, moved = f.moveToPrivateChat(ctx, c, step.Waiting); !moved {
+				return
+			}
+		}
+
+		c.Step = step.Name
+		c.Waiting = step.Waiting
+		c.Expires = time.Now().Add(f.timeout())
+		ctx.saveConversation(c)
+
+		if step.Prompt != nil {
+			ctx.Resp <- step.Prompt(ctx, c)
+		} else {
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Please provide %s", ctx.T(step.Waiting)))
+		}
+		return
+	}
+
+	ctx.EndConversation()
+	if f.Finish != nil {
+		f.Finish(ctx, c)
+	}
+
+	var menuMessageId int
+	if c.Get("menu", &menuMessageId) == nil {
+		ctx.refreshSettingsMenu(menuMessageId, c.AppId())
+	}
+}
+
+func (f *Flow) timeout() time.Duration {
+	if f.Timeout == 0 {
+		return ConversationTimeout
+	}
+
+	return f.Timeout
+}
+
+func (f *Flow) rollback(store *datastore.Datastore, c *Conversation) {
+	if f.Rollback != nil {
+		f.Rollback(store, c)
+	}
+}
+
+func (f *Flow) stepIndex(name string) int {
+	for i, step := range f.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (ctx Context) activeConversation() *Conversation {
+	if ctx.SafeChatId() == 0 {
+		return nil
+	}
+
+	var chat Chat
+	err := ctx.Store.DB().Collection(collections.CHAT).FindOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+		"userid": ctx.UserId(),
+	}, options.FindOne().SetProjection(bson.M{"conversation": 1})).Decode(&chat)
+	if err != nil || !chat.Conversation.Active() {
+		return nil
+	}
+
+	return chat.Conversation
+}
+
+func (ctx Context) saveConversation(c *Conversation) {
+	_, err := ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+		"userid": ctx.UserId(),
+	}, bson.M{
+		"$set": bson.M{
+			"conversation": c,
+		},
+	})
+	utils.PanicOnError(err)
+}
+
+func (ctx Context) EndConversation() {
+	_, err := ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+		"userid": ctx.UserId(),
+	}, bson.M{
+		"$unset": bson.M{
+			"conversation": 1,
+		},
+	})
+	utils.PanicOnError(err)
+}
+
+// CancelConversation rolls back and ends the conversation of the user, expired ones too, and tells whether there was one.
+func (ctx Context) CancelConversation() bool {
+	var chat Chat
+	err := ctx.Store.DB().Collection(collections.CHAT).FindOne(ctx.Store.Context, bson.M{
+		"chatid": ctx.ChatId(),
+		"userid": ctx.UserId(),
+	}, options.FindOne().SetProjection(bson.M{"conversation": 1})).Decode(&chat)
+	if err != nil || chat.Conversation == nil {
+		return false
+	}
+
+	if f, ok := flows[chat.Conversation.Flow]; ok {
+		f.rollback(ctx.Store, chat.Conversation)
+	}
+	ctx.EndConversation()
+
+	return true
+}
+
+// ExpireConversations ends conversations nobody answered in time, rolls them back and lets the users know.
+func ExpireConversations(store *datastore.Datastore, resp chan tgbotapi.Chattable) {
+	c, err := store.DB().Collection(collections.CHAT).Find(store.Context, bson.M{
+		"conversation.expires": bson.M{"$lt": time.Now()},
+	})
+	utils.PanicOnError(err)
+
+	var chats []Chat
+	err = c.All(store.Context, &chats)
+	utils.PanicOnError(err)
+
+	for _, chat := range chats {
+		// the user may have answered or started over meanwhile
+		info, err := store.DB().Collection(collections.CHAT).UpdateOne(store.Context, bson.M{
+			"_id":                  chat.ID,
+			"conversation.expires": chat.Conversation.Expires,
+		}, bson.M{
+			"$unset": bson.M{
+				"conversation": 1,
+			},
+		})
+		if err != nil {
+			utils.LogError(err)
+			continue
+		}
+		if info.ModifiedCount == 0 {
+			continue
+		}
+
+		if f, ok := flows[chat.Conversation.Flow]; ok {
+			f.rollback(store, chat.Conversation)
+		}
+		log.Printf("[ExpireConversations] flow: %s, chatId: %d, userId: %d", chat.Conversation.Flow, chat.ChatId, chat.UserId)
+
+		language := chat.Conversation.Language
+		resp <- tgbotapi.NewMessage(chat.ChatId, i18n.T(language, "Stopped waiting for %s, send the command again to start over",
+			i18n.T(language, chat.Conversation.Waiting)))
+	}
+}
+
+// RemovePartialApps deletes apps which were never completed, apps of conversations in progress are kept.
+func RemovePartialApps(store *datastore.Datastore) {
+	c, err := store.DB().Collection(collections.CHAT).Find(store.Context, bson.M{
+		"conversation.data.app": bson.M{"$exists": true},
+	}, options.Find().SetProjection(bson.M{"conversation": 1}))
+	utils.PanicOnError(err)
+
+	var chats []Chat
+	err = c.All(store.Context, &chats)
+	utils.PanicOnError(err)
+
+	inProgress := bson.A{}
+	for _, chat := range chats {
+		inProgress = append(inProgress, chat.Conversation.AppId())
+	}
+
+	// ids of apps created before the cutoff are lower, this gives a conversation time to complete the app
+	cutoff := primitive.NewObjectIDFromTimestamp(time.Now().Add(-ConversationTimeout))
+	res, err := store.DB().Collection(collections.APPS).DeleteMany(store.Context, bson.M{
+		"_id": bson.M{
+			"$lt":  cutoff,
+			"$nin": inProgress,
+		},
+		"$or": bson.A{
+			bson.M{"packagename": bson.M{"$exists": false}},
+			bson.M{"os": "android", "keyfile": bson.M{"$exists": false}},
+		},
+	})
+	utils.PanicOnError(err)
+
+	if res.DeletedCount > 0 {
+		log.Printf("[RemovePartialApps] removed %d apps", res.DeletedCount)
+	}
+}
+
+// requireApps refuses to start flows which change an app when the user has none.
+func requireApps(ctx Context, c *Conversation) error {
+	if len(ctx.UserApps()) == 0 {
+		return i18n.Errorf("No apps to change")
+	}
+
+	return nil
+}
+
+// chooseAppStep asks to pick one of the apps of the user, its id is stored as "app", see Conversation.AppId.
+func chooseAppStep() Step {
+	return Step{
+		Name:     "app",
+		Waiting:  "choose app",
+		Callback: true,
+		Skip: func(c *Conversation) bool {
+			_, chosen := c.Data["app"]
+			return chosen
+		},
+		Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+			chattable := makeAppChooser(ctx)
+			if chattable == nil {
+				return tgbotapi.NewMessage(ctx.ChatId(), ctx.T("No apps to change"))
+			}
+			return *chattable
+		},
+		Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+			id, err := primitive.ObjectIDFromHex(ctx.Update.CallbackQuery.Data)
+			if err != nil {
+				return nil, i18n.Errorf("Please choose app")
+			}
+			// callback data may be forged, only apps of the user can be chosen
+			app, err := ctx.findUserApp(id)
+			if err == mongo.ErrNoDocuments {
+				return nil, i18n.Errorf("Please choose app")
+			}
+			if err != nil {
+				return nil, err
+			}
+			return app.ID, nil
+		},
+	}
+}
+
+// textStep waits for a non empty text message, which is stored as a string.
+func textStep(name string, waiting string) Step {
+	return Step{
+		Name:    name,
+		Waiting: waiting,
+		Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+			text := strings.TrimSpace(ctx.Update.Message.Text)
+			if text == "" {
+				return nil, i18n.Errorf("Please provide %s", i18n.Text(waiting))
+			}
+			return text, nil
+		},
+	}
+}
+
+// ResetMovedChatStates drops chat states of flows which became conversations, users simply start them again.
+func ResetMovedChatStates(store *datastore.Datastore) {
+	_, err := store.DB().Collection(collections.CHAT).UpdateMany(store.Context, bson.M{
+		"state": bson.M{"$gt": ChatStateNone},
+	}, bson.M{
+		"$set": bson.M{
+			"state": ChatStateNone,
+		},
+		"$unset": bson.M{
+			"customdata": 1,
+		},
+	})
+	utils.LogError(err)
+}
@@ -38,4 +38,16 @@ func (EditMessageConsumer) Name() string {
 	return "EditMessageConsumer"
 }
 
+// ChannelPostConsumer drops posts of channels the bot is an admin of, there is nobody to answer them.
+type ChannelPostConsumer struct {
+}
+
+func (ChannelPostConsumer) Handle(ctx Context) bool {
+	return ctx.Update.ChannelPost != nil || ctx.Update.EditedChannelPost != nil
+}
+
+func (ChannelPostConsumer) Name() string {
+	return "ChannelPostConsumer"
+}
+
 
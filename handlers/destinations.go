@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	RemoveDestinationPrefix = "rmdest_"
+	deliveriesShown         = 20
+)
+
+type destinationType struct {
+	Title   string
+	Waiting string
+	Parse   func(text string) (Destination, error)
+}
+
+const (
+	slackWaiting = "slack incoming webhook url, or bot token and channel: xoxb-... #channel"
+	emailWaiting = "email address, optionally followed by daily or weekly for a digest"
+)
+
+var destinationTypeOrder = []string{"slack", "discord", "teams", "webhook", "email"}
+
+var destinationTypes = map[string]destinationType{
+	"slack": {
+		Title:   "Slack",
+		Waiting: slackWaiting,
+		Parse:   parseSlackDestination,
+	},
+	"discord": {
+		Title:   "Discord",
+		Waiting: "discord webhook url",
+		Parse: func(text string) (Destination, error) {
+			return parseWebhookDestination("discord", text, "discord.com", "discordapp.com")
+		},
+	},
+	"teams": {
+		Title:   "Teams",
+		Waiting: "teams incoming webhook url",
+		Parse: func(text string) (Destination, error) {
+			return parseWebhookDestination("teams", text, "webhook.office.com", "logic.azure.com", "api.powerplatform.com")
+		},
+	},
+	"webhook": {
+		Title:   "Webhook",
+		Waiting: "webhook url",
+		Parse:   parseSignedWebhookDestination,
+	},
+	"email": {
+		Title:   "Email",
+		Waiting: emailWaiting,
+		Parse:   parseEmailDestination,
+	},
+}
+
+func parseSlackDestination(text string) (Destination, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 1 {
+		return parseWebhookDestination("slack", fields[0], "hooks.slack.com")
+	}
+	if len(fields) == 2 && strings.HasPrefix(fields[0], "xox") {
+		return Destination{
+			Type:    "slack",
+			Token:   fields[0],
+			Channel: fields[1],
+		}, nil
+	}
+
+	return Destination{}, i18n.Errorf("Please provide %s", i18n.Text(slackWaiting))
+}
+
+func parseWebhookDestination(destinationType string, text string, hosts ...string) (Destination, error) {
+	u, err := url.Parse(strings.TrimSpace(text))
+	if err != nil || u.Scheme != "https" {
+		return Destination{}, i18n.Errorf("Please provide https url")
+	}
+	if len(hosts) > 0 {
+		allowed := false
+		for _, host := range hosts {
+			allowed = allowed || u.Host == host || strings.HasSuffix(u.Host, "."+host)
+		}
+		if !allowed {
+			return Destination{}, i18n.Errorf("Expected url at %s", strings.Join(hosts, ", "))
+		}
+	}
+
+	return Destination{
+		Type: destinationType,
+		URL:  u.String(),
+	}, nil
+}
+
+func parseSignedWebhookDestination(text string) (Destination, error) {
+	destination, err := parseWebhookDestination("webhook", text)
+	if err != nil {
+		return destination, err
+	}
+
+	destination.Secret = makeSecret()
+
+	return destination, nil
+}
+
+func parseEmailDestination(text string) (Destination, error) {
+	// emails have to carry an unsubscribe link, which is served by the http server of the bot
+	if os.Getenv("SMTP_HOST") == "" || utils.PublicUrl() == "" {
+		return Destination{}, i18n.Errorf("Email delivery is not configured on this bot")
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Destination{}, i18n.Errorf("Please provide %s", i18n.Text(emailWaiting))
+	}
+
+	address, err := mail.ParseAddress(fields[0])
+	if err != nil {
+		return Destination{}, i18n.Errorf("Invalid email address: %s", fields[0])
+	}
+
+	destination := Destination{
+		Type:    "email",
+		Address: address.Address,
+		// used to authorize unsubscribe links
+		Secret: makeSecret(),
+	}
+	if len(fields) == 2 {
+		digest := strings.ToLower(fields[1])
+		if digest != "daily" && digest != "weekly" {
+			return Destination{}, i18n.Errorf("Please provide %s", i18n.Text(emailWaiting))
+		}
+		destination.Digest = digest
+		now := time.Now()
+		destination.LastDigest = &now
+	}
+
+	return destination, nil
+}
+
+func makeSecret() string {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	utils.PanicOnError(err)
+
+	return hex.EncodeToString(secret)
+}
+
+func AddDestinationFlow() *Flow {
+	steps := []Step{
+		chooseAppStep(),
+		{
+			Name:     "type",
+			Waiting:  "destination type",
+			Callback: true,
+			Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+				message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Where should reviews be delivered?"))
+				var buttons []tgbotapi.InlineKeyboardButton
+				for _, name := range destinationTypeOrder {
+					buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(destinationTypes[name].Title, name))
+				}
+				message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons)
+				return message
+			},
+			Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+				name := ctx.Update.CallbackQuery.Data
+				if _, ok := destinationTypes[name]; !ok {
+					return nil, i18n.Errorf("Unknown destination")
+				}
+				return name, nil
+			},
+		},
+	}
+	// every type asks for its own address, only the step of the chosen type is asked
+	for _, name := range destinationTypeOrder {
+		steps = append(steps, destinationStep(name))
+	}
+
+	return registerFlow(&Flow{
+		Id:    "AddDestination",
+		Start: requireApps,
+		Steps: steps,
+		Finish: func(ctx Context, c *Conversation) {
+			var destination Destination
+			utils.PanicOnError(c.Get(c.String("type"), &destination))
+
+			// the secret goes to the private chat only, the chat of the flow may be a group
+			if destination.Type == "webhook" {
+				_, err := ctx.Bot.Send(tgbotapi.NewMessage(int64(ctx.UserId()),
+					ctx.T("Signing secret of %s, save it now: %s", destination.Describe(), destination.Secret)))
+				if err != nil {
+					ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+						"I can't send you the signing secret, open @%s, send /start and add the webhook again", ctx.Bot.Self.UserName))
+					return
+				}
+			}
+
+			_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": c.AppId()}, bson.M{
+				"$push": bson.M{
+					"destinations": destination,
+				},
+			})
+			utils.PanicOnError(err)
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Destination %s added", destination.Describe()))
+			ctx.AppChanges <- 1
+		},
+	})
+}
+
+func destinationStep(name string) Step {
+	t := destinationTypes[name]
+
+	return Step{
+		Name:    name,
+		Waiting: t.Waiting,
+		Skip: func(c *Conversation) bool {
+			return c.String("type") != name
+		},
+		Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+			destination, err := t.Parse(ctx.Update.Message.Text)
+			if err != nil {
+				return nil, err
+			}
+			destination.ID = primitive.NewObjectID()
+
+			// secrets should not stay in the chat history
+			_, err = ctx.Bot.DeleteMessage(tgbotapi.DeleteMessageConfig{ChatID: ctx.ChatId(), MessageID: ctx.Update.Message.MessageID})
+			utils.LogError(err)
+
+			return destination, nil
+		},
+	}
+}
+
+type ListDestinations struct {
+	Handler
+}
+
+func (ListDestinations) Handle(ctx Context) bool {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, app := range ctx.UserApps() {
+		for _, destination := range app.Destinations {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+				fmt.Sprintf("❌ %s: %s", app.GetName(), destination.Describe()),
+				RemoveDestinationPrefix+destination.ID.Hex(),
+			)))
+		}
+	}
+
+	if len(rows) == 0 {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("No destinations yet. /adddestination ?"))
+		return true
+	}
+
+	message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Your destinations, tap to remove:"))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	ctx.Resp <- message
+
+	return true
+}
+
+func (ListDestinations) Name() string {
+	return "ListDestinations"
+}
+
+type RemoveDestination struct {
+	Handler
+}
+
+func (RemoveDestination) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, RemoveDestinationPrefix) {
+		return false
+	}
+
+	id, err := primitive.ObjectIDFromHex(strings.TrimPrefix(query.Data, RemoveDestinationPrefix))
+	utils.PanicOnError(err)
+
+	_, err = ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
+		"userid":          ctx.UserId(),
+		"destinations.id": id,
+	}, bson.M{
+		"$pull": bson.M{
+			"destinations": bson.M{"id": id},
+		},
+	})
+	utils.PanicOnError(err)
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Destination removed")))
+	utils.LogError(err)
+	ctx.AppChanges <- 1
+
+	return true
+}
+
+func (RemoveDestination) Name() string {
+	return "RemoveDestination"
+}
+
+func ShowDeliveriesFlow() *Flow {
+	return registerFlow(&Flow{
+		Id: "ShowDeliveries",
+		Start: func(ctx Context, c *Conversation) error {
+			if len(ctx.UserApps()) == 0 {
+				return i18n.Errorf("No apps yet")
+			}
+			return nil
+		},
+		Steps: []Step{
+			chooseAppStep(),
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			showDeliveries(ctx, c.AppId())
+		},
+	})
+}
+
+func showDeliveries(ctx Context, appId primitive.ObjectID) {
+	c, err := ctx.Store.DB().Collection(collections.DELIVERIES).Find(ctx.Store.Context, bson.M{
+		"appid": appId,
+	}, options.Find().SetSort(bson.M{"created": -1}).SetLimit(deliveriesShown))
+	utils.PanicOnError(err)
+
+	var deliveries []DeliveryLog
+	err = c.All(ctx.Store.Context, &deliveries)
+	utils.PanicOnError(err)
+
+	if len(deliveries) == 0 {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("No deliveries yet"))
+		return
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(ctx.T("Latest deliveries:") + "\n")
+	for _, d := range deliveries {
+		status := "✅"
+		if d.Error != "" {
+			status = "❌ " + truncate(d.Error, 200)
+		}
+		buffer.WriteString(fmt.Sprintf("\n%s %s #%d %s\n%s\n",
+			d.Created.Format("2006-01-02 15:04:05"), d.Destination, d.Attempt, d.ReviewId, status))
+	}
+
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), buffer.String())
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	// Telegram refuses documents over 50MB, keep some room for the multipart overhead.
+	exportPartLimit = 45 * 1024 * 1024
+	exportTimeout   = 10 * time.Minute
+)
+
+const exportRangeWaiting = "date range: <yyyy-mm-dd> [yyyy-mm-dd], or all"
+
+var exportFormatOrder = []string{"csv", "json", "xlsx"}
+
+func ExportFlow() *Flow {
+	return registerFlow(&Flow{
+		Id: "Export",
+		Start: func(ctx Context, c *Conversation) error {
+			if len(ctx.UserApps()) == 0 {
+				return i18n.Errorf("No apps to export")
+			}
+			return nil
+		},
+		Steps: []Step{
+			chooseAppStep(),
+			{
+				Name:    "range",
+				Waiting: exportRangeWaiting,
+				Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+					dateRange := strings.Join(strings.Fields(ctx.Update.Message.Text), " ")
+					if _, err := parseExportRange(dateRange); err != nil {
+						return nil, err
+					}
+					return dateRange, nil
+				},
+			},
+			{
+				Name:     "format",
+				Waiting:  "export format",
+				Callback: true,
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Choose export format"))
+					var buttons []tgbotapi.InlineKeyboardButton
+					for _, format := range exportFormatOrder {
+						buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(strings.ToUpper(format), format))
+					}
+					message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(buttons)
+					return message
+				},
+				Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+					format := ctx.Update.CallbackQuery.Data
+					if _, ok := exportFormats[format]; !ok {
+						return nil, i18n.Errorf("Invalid format")
+					}
+					return format, nil
+				},
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			period, err := parseExportRange(c.String("range"))
+			utils.PanicOnError(err)
+
+			app, err := ctx.findUserApp(c.AppId())
+			utils.PanicOnError(err)
+
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Preparing export..."))
+
+			filter := bson.M{"appid": app.ID}
+			// reviews are refetched when their text changes, so they are filtered by the time they were written
+			if len(period) > 0 {
+				filter["$or"] = reviewTimeFilter(period)
+			}
+			format := c.String("format")
+			parts, err := exportReviews(ctx, app, filter, format, exportFormats[format])
+			if err != nil {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Export failed: %s", ctx.Localize(err)))
+				return
+			}
+			if parts == 0 {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("No reviews in this range"))
+			}
+		},
+	})
+}
+
+func parseExportRange(text string) (bson.M, error) {
+	usage := i18n.Errorf("Please provide %s", i18n.Text(exportRangeWaiting))
+
+	if strings.EqualFold(text, "all") {
+		return bson.M{}, nil
+	}
+
+	dates := strings.Fields(text)
+	if len(dates) == 0 || len(dates) > 2 {
+		return nil, usage
+	}
+
+	from, err := time.Parse(searchDateFormat, dates[0])
+	if err != nil {
+		return nil, usage
+	}
+	period := bson.M{"$gte": from}
+
+	if len(dates) == 2 {
+		to, err := time.Parse(searchDateFormat, dates[1])
+		if err != nil || to.Before(from) {
+			return nil, usage
+		}
+		period["$lt"] = to.Add(24 * time.Hour)
+	}
+
+	return period, nil
+}
+
+// exportReviews streams matching reviews into documents no larger than exportPartLimit and returns how many were sent.
+func exportReviews(ctx Context,
+	app Application,
+	filter bson.M,
+	format string,
+	newWriter func(w io.Writer) (reviewWriter, error)) (int, error) {
+
+	storeContext, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	c, err := ctx.Store.DB().Collection(collections.REVIEWS).Aggregate(storeContext, sortByReviewTime(filter, 1), options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close(storeContext)
+
+	var buffer bytes.Buffer
+	var writer reviewWriter
+	parts := 0
+
+	send := func() error {
+		if err := writer.Close(); err != nil {
+			return err
+		}
+		parts++
+
+		name := fmt.Sprintf("reviews_%s_%s.%s", app.PackageName, time.Now().Format("20060102"), format)
+		if parts > 1 || buffer.Len() >= exportPartLimit {
+			name = fmt.Sprintf("reviews_%s_%s_part%d.%s", app.PackageName, time.Now().Format("20060102"), parts, format)
+		}
+		log.Printf("[Export] Sending %s, %d bytes", name, buffer.Len())
+
+		ctx.Resp <- tgbotapi.NewDocumentUpload(ctx.ChatId(), tgbotapi.FileBytes{
+			Name:  name,
+			Bytes: append([]byte(nil), buffer.Bytes()...),
+		})
+		buffer.Reset()
+		writer = nil
+		return nil
+	}
+
+	for c.Next(storeContext) {
+		var review UserReview
+		if err := c.Decode(&review); err != nil {
+			return parts, err
+		}
+		review.AppName = app.GetName()
+
+		if writer == nil {
+			if writer, err = newWriter(&buffer); err != nil {
+				return parts, err
+			}
+		}
+		if err := writer.Write(newExportedReview(review)); err != nil {
+			return parts, err
+		}
+
+		if buffer.Len() >= exportPartLimit {
+			if err := send(); err != nil {
+				return parts, err
+			}
+		}
+	}
+	if err := c.Err(); err != nil {
+		return parts, err
+	}
+
+	if writer != nil {
+		return parts, send()
+	}
+
+	return parts, nil
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type exportedReview struct {
+	ReviewId       string     `json:"reviewId"`
+	App            string     `json:"app"`
+	UserName       string     `json:"userName"`
+	Device         string     `json:"device"`
+	SdkInt         int        `json:"sdkInt,omitempty"`
+	Rating         int        `json:"rating"`
+	AppVersion     string     `json:"appVersion"`
+	AppBuildNumber int64      `json:"appBuildNumber,omitempty"`
+	Time           *time.Time `json:"time,omitempty"`
+	Fetched        time.Time  `json:"fetched"`
+	Language       string     `json:"language,omitempty"`
+	Text           string     `json:"text"`
+	OriginalText   string     `json:"originalText,omitempty"`
+	Replied        bool       `json:"replied"`
+	ReplyText      string     `json:"replyText,omitempty"`
+	ReplyTime      *time.Time `json:"replyTime,omitempty"`
+	Link           string     `json:"link,omitempty"`
+}
+
+var exportedColumns = []string{
+	"Review ID", "App", "User", "Device", "SDK", "Rating", "App version", "Build", "Time", "Fetched",
+	"Language", "Text", "Original text", "Replied", "Reply text", "Reply time", "Link",
+}
+
+func newExportedReview(r UserReview) exportedReview {
+	exported := exportedReview{
+		ReviewId:       r.ReviewId,
+		App:            r.AppName,
+		UserName:       r.UserName,
+		Device:         r.Device,
+		SdkInt:         r.SdkInt,
+		Rating:         r.Rating,
+		AppVersion:     r.AppVersion,
+		AppBuildNumber: r.AppBuildNumber,
+		Fetched:        r.Fetched,
+		Language:       r.Language,
+		Text:           r.Text,
+		OriginalText:   r.OriginalText,
+		Replied:        r.ReplyText != "",
+		ReplyText:      r.ReplyText,
+		ReplyTime:      r.ReplyTime,
+		Link:           r.Link(),
+	}
+	if !r.Time.IsZero() {
+		exported.Time = &r.Time
+	}
+
+	return exported
+}
+
+func (r exportedReview) columns() []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		r.ReviewId,
+		r.App,
+		r.UserName,
+		r.Device,
+		strconv.Itoa(r.SdkInt),
+		strconv.Itoa(r.Rating),
+		r.AppVersion,
+		strconv.FormatInt(r.AppBuildNumber, 10),
+		formatTime(r.Time),
+		formatTime(&r.Fetched),
+		r.Language,
+		r.Text,
+		r.OriginalText,
+		strconv.FormatBool(r.Replied),
+		r.ReplyText,
+		formatTime(r.ReplyTime),
+		r.Link,
+	}
+}
+
+// reviewWriter serializes reviews into a single export file.
+type reviewWriter interface {
+	Write(r exportedReview) error
+	Close() error
+}
+
+var exportFormats = map[string]func(w io.Writer) (reviewWriter, error){
+	"csv":  newCsvReviewWriter,
+	"json": newJsonReviewWriter,
+	"xlsx": newXlsxReviewWriter,
+}
+
+type csvReviewWriter struct {
+	writer *csv.Writer
+}
+
+func newCsvReviewWriter(w io.Writer) (reviewWriter, error) {
+	writer := csv.NewWriter(w)
+	return csvReviewWriter{writer: writer}, writer.Write(exportedColumns)
+}
+
+func (w csvReviewWriter) Write(r exportedReview) error {
+	return w.writer.Write(r.columns())
+}
+
+func (w csvReviewWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonReviewWriter struct {
+	w     io.Writer
+	first bool
+}
+
+func newJsonReviewWriter(w io.Writer) (reviewWriter, error) {
+	_, err := io.WriteString(w, "[\n")
+	return &jsonReviewWriter{w: w, first: true}, err
+}
+
+func (w *jsonReviewWriter) Write(r exportedReview) error {
+	if !w.first {
+		if _, err := io.WriteString(w.w, ",\n"); err != nil {
+			return err
+		}
+	}
+	w.first = false
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(b)
+	return err
+}
+
+func (w *jsonReviewWriter) Close() error {
+	_, err := io.WriteString(w.w, "\n]\n")
+	return err
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Reviews" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxReviewWriter writes a minimal single sheet workbook with inline strings, streaming rows into the zip.
+type xlsxReviewWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+func newXlsxReviewWriter(w io.Writer) (reviewWriter, error) {
+	archive := zip.NewWriter(w)
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(f, file.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	writer := &xlsxReviewWriter{archive: archive, sheet: sheet}
+	if _, err = io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return writer, writer.writeRow(exportedColumns)
+}
+
+func (w *xlsxReviewWriter) Write(r exportedReview) error {
+	return w.writeRow(r.columns())
+}
+
+func (w *xlsxReviewWriter) writeRow(columns []string) error {
+	if _, err := io.WriteString(w.sheet, "<row>"); err != nil {
+		return err
+	}
+	for _, column := range columns {
+		if _, err := fmt.Fprint(w.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w.sheet, []byte(column)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w.sheet, "</t></is></c>"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w.sheet, "</row>")
+	return err
+}
+
+func (w *xlsxReviewWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return w.archive.Close()
+}
@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"fmt"
 	"google-play-review-bot/collections"
 	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
 	"google-play-review-bot/utils"
 	"io"
 	"log"
@@ -27,6 +27,47 @@ type Context struct {
 	AppChanges chan int
 	Store      *datastore.Datastore
 	Bot        *tgbotapi.BotAPI
+	// Language of the user who sent the update, see DetectLanguage
+	Language string
+}
+
+// TrackedMessage lets the sender of a message learn which telegram message was created for it, or why it failed.
+type TrackedMessage struct {
+	tgbotapi.Chattable
+	OnSent   func(message tgbotapi.Message)
+	OnFailed func(err error)
+}
+
+// T translates a message into the language of the user, see i18n.T.
+func (ctx Context) T(key string, args ...interface{}) string {
+	return i18n.T(ctx.Language, key, args...)
+}
+
+// Localize renders an error for the user, translating errors created with i18n.Errorf.
+func (ctx Context) Localize(err error) string {
+	return i18n.Localize(ctx.Language, err)
+}
+
+// DetectLanguage picks the language chosen with /language, falling back to the language of the telegram client.
+func DetectLanguage(store *datastore.Datastore, update tgbotapi.Update) string {
+	var from *tgbotapi.User
+	switch {
+	case update.Message != nil:
+		from = update.Message.From
+	case update.CallbackQuery != nil:
+		from = update.CallbackQuery.From
+	case update.InlineQuery != nil:
+		from = update.InlineQuery.From
+	}
+	if from == nil {
+		return i18n.Default
+	}
+
+	if language := LoadChatSettings(store, int64(from.ID)).Language; language != "" {
+		return language
+	}
+
+	return i18n.Normalize(from.LanguageCode)
 }
 
 func (ctx Context) EnsureCommand(command string) bool {
@@ -46,6 +87,53 @@ func (ctx Context) SafeChatId() int64 {
 	return 0
 }
 
+func (ctx Context) IsPrivateChat() bool {
+	if ctx.Update.Message != nil {
+		return ctx.Update.Message.Chat.IsPrivate()
+	}
+	if ctx.Update.CallbackQuery != nil && ctx.Update.CallbackQuery.Message != nil {
+		return ctx.Update.CallbackQuery.Message.Chat.IsPrivate()
+	}
+	return false
+}
+
+// IsChatAdmin tells whether the user may manage apps of the chat, everyone is the admin of their private chat.
+func (ctx Context) IsChatAdmin() bool {
+	if ctx.IsPrivateChat() {
+		return true
+	}
+
+	member, err := ctx.Bot.GetChatMember(tgbotapi.ChatConfigWithUser{
+		ChatID: ctx.ChatId(),
+		UserID: ctx.UserId(),
+	})
+	if err != nil {
+		utils.LogError(err)
+		return false
+	}
+
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// privateContext is the context of the same update as if it came to the private chat with the user.
+func (ctx Context) privateContext() Context {
+	chat := &tgbotapi.Chat{ID: int64(ctx.UserId()), Type: "private"}
+	if ctx.Update.Message != nil {
+		message := *ctx.Update.Message
+		message.Chat = chat
+		ctx.Update.Message = &message
+	}
+	if ctx.Update.CallbackQuery != nil && ctx.Update.CallbackQuery.Message != nil {
+		query := *ctx.Update.CallbackQuery
+		message := *query.Message
+		message.Chat = chat
+		query.Message = &message
+		ctx.Update.CallbackQuery = &query
+	}
+
+	return ctx
+}
+
 func (ctx Context) ChatId() int64 {
 	chatId := ctx.SafeChatId()
 	if chatId == 0 {
@@ -59,89 +147,64 @@ func (ctx Context) UserId() int {
 	if ctx.Update.Message != nil {
 		return ctx.Update.Message.From.ID
 	}
-	if ctx.Update.CallbackQuery.Message != nil {
+	if ctx.Update.CallbackQuery != nil {
 		return ctx.Update.CallbackQuery.From.ID
 	}
+	if ctx.Update.InlineQuery != nil {
+		return ctx.Update.InlineQuery.From.ID
+	}
 	panic("don't know where to get user id")
 }
 
-func (ctx Context) EnsureChatState(state int) (bool, *Chat) {
-	chatCollection := ctx.Store.DB().Collection(collections.CHAT)
-
-	chat := Chat{}
-	r := chatCollection.FindOne(ctx.Store.Context, bson.M{
-		"chatid": ctx.ChatId(),
+func (ctx Context) UserApps() []Application {
+	var apps []Application
+	c, err := ctx.Store.DB().Collection(collections.APPS).Find(ctx.Store.Context, bson.M{
 		"userid": ctx.UserId(),
 	})
+	utils.PanicOnError(err)
 
-	if err := r.Err(); err != nil {
-		return false, nil
-	}
-
-	err := r.Decode(&chat)
+	err = c.All(ctx.Store.Context, &apps)
+	utils.PanicOnError(err)
 
-	return err == nil && chat.State == state, &chat
+	return apps
 }
 
+// ChangeChatState resets chat states left from before conversations, see ResetMovedChatStates.
 func (ctx Context) ChangeChatState(newState int) error {
-	return ctx.ChangeChatStateWithNextState(newState, ChatStateNone)
-}
-
-func (ctx Context) ChangeChatStateWithNextState(newState int, nextState int) error {
 	_, err := ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
 		"chatid": ctx.ChatId(),
 		"userid": ctx.UserId(),
 	}, bson.M{
 		"$set": bson.M{
-			"state":      newState,
-			"customdata": nextState,
+			"state": newState,
+		},
+		"$unset": bson.M{
+			"customdata": 1,
 		},
 	})
 
 	return err
 }
 
-func (ctx Context) ChangeChatStateOrAnswerDefault(newState int) bool {
-	return ctx.ChangeChatStateWithNextStateOrAnswerDefault(newState, ChatStateNone)
-}
-
-func (ctx Context) ChangeChatStateWithNextStateOrAnswerDefault(newState int, nextState int) bool {
-	log.Printf("chatid = %d, userid = %d", ctx.ChatId(), ctx.UserId())
-
-	info, err := ctx.Store.DB().Collection(collections.CHAT).UpdateOne(ctx.Store.Context, bson.M{
-		"chatid": ctx.ChatId(),
-		"userid": ctx.UserId(),
-		"state":  ChatStateNone,
-	}, bson.M{
-		"$set": bson.M{
-			"state":      newState,
-			"customdata": nextState,
-		},
-	}, options.Update().SetUpsert(true))
-
-	utils.LogError(err)
-	utils.LogStruct(info)
-
-	// err = when chat already exists and state not match
-	if err == nil && (info.ModifiedCount != 0 || info.UpsertedID != nil || info.MatchedCount != 0) {
-		return true
-	}
-
-	var partialChat struct{ State int }
+func (ctx Context) loadChatState() Chat {
+	var chat Chat
 	ctx.Store.DB().Collection(collections.CHAT).FindOne(ctx.Store.Context, bson.M{
 		"chatid": ctx.ChatId(),
 		"userid": ctx.UserId(),
-	}, options.FindOne().SetProjection(bson.M{"state": 1})).Decode(&partialChat)
+	}, options.FindOne().SetProjection(bson.M{"state": 1, "conversation": 1})).Decode(&chat)
 
-	if partialChat.State == newState {
-		return true
-	}
+	return chat
+}
 
-	utils.LogStruct(partialChat)
+// answerWaiting tells the user what the bot is waiting for in the conversation.
+func (ctx Context) answerWaiting(chat Chat) {
+	waiting := "nothing"
+	if chat.Conversation.Active() {
+		waiting = chat.Conversation.Waiting
+	}
 
-	responseMessage := fmt.Sprintf("I'm waiting for: %s\n", ChatStateToWaitingString(partialChat.State))
+	responseMessage := ctx.T("I'm waiting for: %s\n", ctx.T(waiting))
 	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), responseMessage)
-	return false
 }
 
 func (ctx Context) downloadFile(fileId string) (io.ReadCloser, error) {
@@ -159,13 +222,9 @@ func (ctx Context) downloadFile(fileId string) (io.ReadCloser, error) {
 	return resp.Body, nil
 }
 
-func (ctx Context) SetKeyFile(buf []byte) {
+func (ctx Context) SetKeyFile(appId primitive.ObjectID, buf []byte) {
 	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
-		"chatid": ctx.ChatId(),
-		"userid": ctx.UserId(),
-		"keyfile": bson.M{
-			"$exists": false,
-		},
+		"_id": appId,
 	}, bson.M{
 		"$set": bson.M{
 			"keyfile": buf,
@@ -189,6 +248,17 @@ func (ctx Context) MigrateChatId(oldId int64, newId int64) {
 		utils.LogError(err)
 	}
 
+	_, err = ctx.Store.DB().Collection(collections.CHAT_SETTINGS).UpdateMany(ctx.Store.Context, bson.M{
+		"chatid": oldId,
+	}, bson.M{
+		"$set": bson.M{
+			"chatid": newId,
+		},
+	})
+	if err != nil {
+		utils.LogError(err)
+	}
+
 	_, err = ctx.Store.DB().Collection(collections.APPS).UpdateMany(ctx.Store.Context, bson.M{
 		"chatid": oldId,
 	}, bson.M{
@@ -202,7 +272,7 @@ func (ctx Context) MigrateChatId(oldId int64, newId int64) {
 }
 
 func (ctx Context) BindAppToChatId(appId primitive.ObjectID, chatId int64) {
-	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
+	res, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
 		"_id": appId,
 		"chatid": bson.M{
 			"$exists": false,
@@ -212,14 +282,20 @@ func (ctx Context) BindAppToChatId(appId primitive.ObjectID, chatId int64) {
 			"chatid": chatId,
 		},
 		"$unset": bson.M{
-			"lastreview":   1,
-			"lastreviewid": 1,
+			"lastreview":      1,
+			"lastreviewid":    1,
+			"messagethreadid": 1,
+			"unreachable":     1,
 		},
 	})
 	utils.PanicOnError(err)
 	log.Printf("[BindAppToChatId] appId: %v, chatId: %v", appId, chatId)
 
 	ctx.AppChanges <- 1
+
+	if res.ModifiedCount > 0 {
+		ctx.createAppTopicIfForum(appId, chatId)
+	}
 }
 
 func (ctx Context) SaveOS(os string) primitive.ObjectID {
@@ -235,13 +311,9 @@ func (ctx Context) SaveOS(os string) primitive.ObjectID {
 	return res.InsertedID.(primitive.ObjectID)
 }
 
-func (ctx Context) SavePackageName(packageName string) error {
+func (ctx Context) SavePackageName(appId primitive.ObjectID, packageName string) error {
 	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{
-		"chatid": ctx.ChatId(),
-		"userid": ctx.UserId(),
-		"packagename": bson.M{
-			"$exists": false,
-		},
+		"_id": appId,
 	}, bson.M{
 		"$set": bson.M{
 			"packagename": packageName,
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// hashtagKinds lists the hashtags which may be appended to posted reviews, in the order they are rendered.
+var hashtagKinds = []string{"rating", "version", "os", "manufacturer", "country"}
+
+const hashtagsWaiting = "hashtags to add: rating version os manufacturer country, or all, or off"
+
+func hashtag(s string) string {
+	return strings.Trim(hashtagRegexp.ReplaceAllString(s, "_"), "_")
+}
+
+// Hashtags generates the enabled hashtags of the app for the review, so the chat can be filtered by tapping them.
+func (r UserReview) Hashtags(kinds []string) []string {
+	var tags []string
+	add := func(prefix string, value string) {
+		if value = hashtag(value); value != "" {
+			tags = append(tags, "#"+prefix+value)
+		}
+	}
+
+	for _, kind := range kinds {
+		switch kind {
+		case "rating":
+			if r.Rating > 0 {
+				add("rating", fmt.Sprint(r.Rating))
+			}
+		case "version":
+			add("v", r.AppVersion)
+		case "os":
+			if r.SdkInt > 0 {
+				add("android", sdkIntToString(r.SdkInt))
+			}
+		case "manufacturer":
+			add("", strings.ToLower(r.Manufacturer))
+		case "country":
+			add("country_", r.CountryCode())
+		}
+	}
+
+	return tags
+}
+
+func parseHashtagKinds(text string) ([]string, error) {
+	fields := strings.Fields(strings.ToLower(strings.ReplaceAll(text, ",", " ")))
+	if len(fields) == 1 && fields[0] == "off" {
+		return []string{}, nil
+	}
+	if len(fields) == 1 && fields[0] == "all" {
+		return hashtagKinds, nil
+	}
+
+	known := map[string]bool{}
+	for _, kind := range hashtagKinds {
+		known[kind] = true
+	}
+
+	enabled := map[string]bool{}
+	for _, field := range fields {
+		if !known[field] {
+			return nil, i18n.Errorf("Unknown hashtag %q, expected some of: %s, or all, or off", field, strings.Join(hashtagKinds, " "))
+		}
+		enabled[field] = true
+	}
+	if len(enabled) == 0 {
+		return nil, i18n.Errorf("Please provide %s", i18n.Text(hashtagsWaiting))
+	}
+
+	var kinds []string
+	for _, kind := range hashtagKinds {
+		if enabled[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	return kinds, nil
+}
+
+func ChangeHashtagsFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeHashtags",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			{
+				Name:    "hashtags",
+				Waiting: hashtagsWaiting,
+				Receive: func(ctx Context, c *Conversation) (interface{}, error) {
+					return parseHashtagKinds(ctx.Update.Message.Text)
+				},
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			var kinds []string
+			utils.PanicOnError(c.Get("hashtags", &kinds))
+			ctx.updateApp(c.AppId(), bson.M{"hashtags": kinds})
+
+			if len(kinds) == 0 {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Hashtags turned off"))
+			} else {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Hashtags changed, e.g. %s", strings.Join(sampleReview.Hashtags(kinds), " ")))
+			}
+		},
+	})
+}
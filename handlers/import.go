@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const importTimeout = 5 * time.Minute
+
+var reviewReportNameRegexp = regexp.MustCompile(`^reviews_(.+)_(\d{6})\.csv$`)
+
+// ReviewReportReceiver imports monthly review reports from Play Console, which go back further than the API.
+type ReviewReportReceiver struct {
+	Handler
+}
+
+func (ReviewReportReceiver) Handle(ctx Context) bool {
+	message := ctx.Update.Message
+	if message == nil || message.Document == nil {
+		return false
+	}
+	match := reviewReportNameRegexp.FindStringSubmatch(message.Document.FileName)
+	if match == nil {
+		return false
+	}
+	packageName := match[1]
+
+	var app Application
+	err := ctx.Store.DB().Collection(collections.APPS).FindOne(ctx.Store.Context, bson.M{
+		"userid":      ctx.UserId(),
+		"packagename": packageName,
+		"os":          "android",
+	}).Decode(&app)
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("You have no android app with packageName = %s", packageName))
+		return true
+	}
+
+	reader, err := ctx.downloadFile(message.Document.FileID)
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Error downloading file: %s", ctx.Localize(err)))
+		return true
+	}
+	defer reader.Close()
+
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Error downloading file: %s", ctx.Localize(err)))
+		return true
+	}
+
+	reviews, err := parseReviewReport(decodeReviewReport(buf), app)
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Error parsing report: %s", ctx.Localize(err)))
+		return true
+	}
+
+	imported, err := importReviews(ctx, reviews)
+	if err != nil {
+		utils.LogError(err)
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Import failed after %d new reviews: %s", imported, ctx.Localize(err)))
+		return true
+	}
+	log.Printf("[%s] Imported %d of %d reviews from %s", app.PackageName, imported, len(reviews), message.Document.FileName)
+
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Imported %d reviews into %s, %d were already known",
+		imported, app.GetName(), len(reviews)-imported))
+
+	return true
+}
+
+func (ReviewReportReceiver) Name() string {
+	return "ReviewReportReceiver"
+}
+
+// importReviews inserts reviews which are not known yet in one batch and returns how many were inserted,
+// reports of a month may have thousands of rows, so it doesn't use the short context of the handler.
+func importReviews(ctx Context, reviews []UserReview) (int, error) {
+	if len(reviews) == 0 {
+		return 0, nil
+	}
+
+	var models []mongo.WriteModel
+	for _, review := range reviews {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"appid":    review.AppId,
+				"reviewid": review.ReviewId,
+			}).
+			SetUpdate(bson.M{"$setOnInsert": review}).
+			SetUpsert(true))
+	}
+
+	storeContext, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	res, err := ctx.Store.DB().Collection(collections.REVIEWS).BulkWrite(storeContext, models, options.BulkWrite().SetOrdered(false))
+	if res == nil {
+		return 0, err
+	}
+
+	return int(res.UpsertedCount), err
+}
+
+// decodeReviewReport converts reports to UTF-8, Play Console ships them as UTF-16 with a byte order mark.
+func decodeReviewReport(buf []byte) []byte {
+	var bigEndian bool
+	switch {
+	case bytes.HasPrefix(buf, []byte{0xFF, 0xFE}):
+		bigEndian = false
+	case bytes.HasPrefix(buf, []byte{0xFE, 0xFF}):
+		bigEndian = true
+	default:
+		return bytes.TrimPrefix(buf, []byte{0xEF, 0xBB, 0xBF})
+	}
+
+	buf = buf[2:]
+	units := make([]uint16, len(buf)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(buf[2*i])<<8 | uint16(buf[2*i+1])
+		} else {
+			units[i] = uint16(buf[2*i+1])<<8 | uint16(buf[2*i])
+		}
+	}
+
+	return []byte(string(utf16.Decode(units)))
+}
+
+func parseReviewReport(buf []byte, app Application) ([]UserReview, error) {
+	reader := csv.NewReader(bytes.NewReader(buf))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"Star Rating", "Review Last Update Millis Since Epoch"} {
+		if _, ok := columns[name]; !ok {
+			return nil, i18n.Errorf("missing column %q", name)
+		}
+	}
+
+	var reviews []UserReview
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		column := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		millis := func(name string) *time.Time {
+			ms, err := strconv.ParseInt(column(name), 10, 64)
+			if err != nil || ms == 0 {
+				return nil
+			}
+			t := time.Unix(0, ms*int64(time.Millisecond))
+			return &t
+		}
+
+		rating, err := strconv.Atoi(column("Star Rating"))
+		if err != nil {
+			continue
+		}
+		updated := millis("Review Last Update Millis Since Epoch")
+		if updated == nil {
+			continue
+		}
+		buildNumber, _ := strconv.ParseInt(column("App Version Code"), 10, 64)
+
+		text := column("Review Text")
+		if title := column("Review Title"); title != "" {
+			text = title + "\t" + text
+		}
+
+		review := UserReview{
+			AppId:          app.ID,
+			ReviewId:       reviewIdFromLink(column("Review Link")),
+			Device:         column("Device"),
+			Text:           text,
+			Time:           *updated,
+			Fetched:        *updated,
+			Rating:         rating,
+			AppVersion:     column("App Version Name"),
+			AppBuildNumber: buildNumber,
+			AppName:        app.GetName(),
+			Language:       column("Reviewer Language"),
+			ReplyText:      column("Developer Reply Text"),
+			ReplyTime:      millis("Developer Reply Millis Since Epoch"),
+			Imported:       true,
+			Url:            column("Review Link"),
+		}
+		if review.ReviewId == "" {
+			review.ReviewId = fmt.Sprintf("report:%d:%s:%d", updated.Unix(), review.Device, rating)
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
+
+func reviewIdFromLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return u.Query().Get("reviewId")
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/utils"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	inlinePageSize          = 20
+	inlineDescriptionLength = 100
+)
+
+type InlineReviewLookup struct {
+	Handler
+}
+
+func (InlineReviewLookup) Handle(ctx Context) bool {
+	query := ctx.Update.InlineQuery
+	if query == nil {
+		return false
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		IsPersonal:    true,
+		Results:       []interface{}{},
+	}
+
+	apps := ctx.UserApps()
+	filter, err := makeSearchFilter(query.Query, apps)
+	if err != nil {
+		answer.Results = append(answer.Results, tgbotapi.NewInlineQueryResultArticle("error", ctx.Localize(err), ctx.T(searchUsageResponse)))
+		_, err = ctx.Bot.AnswerInlineQuery(answer)
+		utils.LogError(err)
+		return true
+	}
+
+	offset, _ := strconv.Atoi(query.Offset)
+	c, err := ctx.Store.DB().Collection(collections.REVIEWS).Find(ctx.Store.Context, filter, options.Find().
+		SetSort(bson.M{"fetched": -1}).
+		SetSkip(int64(offset)).
+		SetLimit(inlinePageSize))
+	utils.PanicOnError(err)
+
+	var reviews []UserReview
+	err = c.All(ctx.Store.Context, &reviews)
+	utils.PanicOnError(err)
+
+	// inline results are sent to arbitrary chats, so the settings of the private chat with the user are used
+	settings := LoadChatSettings(ctx.Store, int64(ctx.UserId()))
+	appsById := map[primitive.ObjectID]Application{}
+	for _, app := range apps {
+		appsById[app.ID] = app
+	}
+
+	for _, r := range reviews {
+		article := tgbotapi.NewInlineQueryResultArticleHTML(r.ID.Hex(),
+			fmt.Sprintf("%s %s %s", strings.Repeat("★", r.Rating), r.AppName, r.AppVersion),
+			appsById[r.AppId].ReviewMessage(0, r, settings).Text)
+		article.Description = truncate(strings.TrimSpace(r.Text), inlineDescriptionLength)
+		answer.Results = append(answer.Results, article)
+	}
+	if len(reviews) == inlinePageSize {
+		answer.NextOffset = strconv.Itoa(offset + inlinePageSize)
+	}
+
+	_, err = ctx.Bot.AnswerInlineQuery(answer)
+	utils.LogError(err)
+
+	return true
+}
+
+func (InlineReviewLookup) Name() string {
+	return "InlineReviewLookup"
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const SetLanguagePrefix = "setlang_"
+
+// ChangeUserLanguage lets users override the language of the bot, which otherwise follows their telegram client.
+type ChangeUserLanguage struct {
+	Handler
+}
+
+func (ChangeUserLanguage) Handle(ctx Context) bool {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, language := range i18n.Languages {
+		name := i18n.Name(language)
+		if language == ctx.Language {
+			name = "✅ " + name
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(name, SetLanguagePrefix+language)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+		ctx.T("Same as telegram"), SetLanguagePrefix)))
+
+	message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Choose the language of the bot"))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	ctx.Resp <- message
+
+	return true
+}
+
+func (ChangeUserLanguage) Name() string {
+	return "ChangeUserLanguage"
+}
+
+type UserLanguageReceiver struct {
+	Handler
+}
+
+func (UserLanguageReceiver) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, SetLanguagePrefix) {
+		return false
+	}
+
+	language := strings.TrimPrefix(query.Data, SetLanguagePrefix)
+	update := bson.M{"$unset": bson.M{"language": 1}}
+	if language != "" {
+		language = i18n.Normalize(language)
+		update = bson.M{"$set": bson.M{"language": language}}
+	} else {
+		language = i18n.Normalize(query.From.LanguageCode)
+	}
+
+	// the setting belongs to the user, so it is kept in the settings of the private chat with them
+	_, err := ctx.Store.DB().Collection(collections.CHAT_SETTINGS).UpdateOne(ctx.Store.Context, bson.M{
+		"chatid": int64(ctx.UserId()),
+	}, update, options.Update().SetUpsert(true))
+	utils.PanicOnError(err)
+
+	ctx.Language = language
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Language changed")))
+	utils.LogError(err)
+
+	if query.Message != nil {
+		ctx.Resp <- tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID,
+			ctx.T("The bot will talk to you in %s", i18n.Name(language)))
+	}
+
+	return true
+}
+
+func (UserLanguageReceiver) Name() string {
+	return "UserLanguageReceiver"
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	// telegram limits the text of a message, after entities are parsed, to 4096 UTF-16 code units
+	telegramMessageLimit    = 4096
+	ShowFullReviewPrefix    = "fullreview_"
+	ToggleTranslationPrefix = "translate_"
+)
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// telegramLength measures HTML text the way telegram does, tags are not counted and entities count as one character.
+func telegramLength(text string) int {
+	return utf16Length(html.UnescapeString(htmlTagRegexp.ReplaceAllString(text, "")))
+}
+
+func utf16Length(text string) int {
+	n := 0
+	for _, r := range text {
+		n += utf16RuneLen(r)
+	}
+
+	return n
+}
+
+func utf16RuneLen(r rune) int {
+	if r1, _ := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+		return 2
+	}
+
+	return 1
+}
+
+// truncateUTF16 cuts text to at most limit UTF-16 code units, including the ellipsis.
+func truncateUTF16(text string, limit int) string {
+	if utf16Length(text) <= limit {
+		return text
+	}
+	if limit < 1 {
+		return ""
+	}
+
+	n := 0
+	for i, r := range text {
+		n += utf16RuneLen(r)
+		if n > limit-1 {
+			return strings.TrimSpace(text[:i]) + "…"
+		}
+	}
+
+	return text
+}
+
+// splitUTF16 splits text into chunks fitting the limit, breaking on new lines or spaces when possible.
+func splitUTF16(text string, limit int) []string {
+	var chunks []string
+	for utf16Length(text) > limit {
+		end, n := 0, 0
+		for i, r := range text {
+			n += utf16RuneLen(r)
+			if n > limit {
+				end = i
+				break
+			}
+		}
+		// a chunk has at least one rune, even if it doesn't fit, so the loop makes progress
+		if end == 0 {
+			_, size := utf8.DecodeRuneInString(text)
+			end = size
+		}
+
+		cut := strings.LastIndex(text[:end], "\n")
+		if cut < end/2 {
+			cut = strings.LastIndex(text[:end], " ")
+		}
+		if cut < end/2 || cut == 0 {
+			cut = end
+		}
+
+		chunks = append(chunks, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+
+	return chunks
+}
+
+// ReviewMessage builds the telegram message for the review, long reviews are truncated and get a button to show them in full.
+func (a Application) ReviewMessage(chatId int64, r UserReview, settings ChatSettings) tgbotapi.MessageConfig {
+	text, truncated := a.renderReview(r, false, settings)
+
+	message := tgbotapi.NewMessage(chatId, text)
+	message.ParseMode = tgbotapi.ModeHTML
+	message.DisableWebPagePreview = true
+	if keyboard := reviewKeyboard(r, false, truncated, settings.Language); keyboard != nil {
+		message.ReplyMarkup = *keyboard
+	}
+
+	return message
+}
+
+// renderReview formats the review to fit a telegram message, original puts the original text first and the translation below it.
+func (a Application) renderReview(r UserReview, original bool, settings ChatSettings) (string, bool) {
+	if original && r.HasOriginalText() {
+		r.Text, r.OriginalText = r.OriginalText, r.Text
+		r.Language = a.TranslateLanguage
+	}
+	text := a.FormatReview(r, settings)
+	full := text
+
+	truncated := r
+	for i := 0; i < 3 && telegramLength(text) > telegramMessageLimit; i++ {
+		// the collapsed text goes first, then the main text is shortened by the overflow
+		if truncated.HasOriginalText() {
+			truncated.OriginalText = ""
+		} else {
+			overflow := telegramLength(text) - telegramMessageLimit
+			truncated.Text = truncateUTF16(truncated.Text, utf16Length(truncated.Text)-overflow-1)
+		}
+		text = a.FormatReview(truncated, settings)
+	}
+	if telegramLength(text) > telegramMessageLimit {
+		text = html.EscapeString(truncateUTF16(r.FormatIn(settings), telegramMessageLimit))
+	}
+
+	return text, text != full
+}
+
+// reviewKeyboard returns buttons for a posted review, the mode of the message is kept in the callback data.
+// Buttons are seen by everybody in the chat, so they are in the language of the chat rather than of the user.
+func reviewKeyboard(r UserReview, original bool, truncated bool, language string) *tgbotapi.InlineKeyboardMarkup {
+	if r.ID.IsZero() {
+		return nil
+	}
+
+	mode := "t"
+	if original {
+		mode = "o"
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if r.HasOriginalText() {
+		if original {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(language, "Translate"), ToggleTranslationPrefix+r.ID.Hex()+"_t"))
+		} else {
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(language, "Show original"), ToggleTranslationPrefix+r.ID.Hex()+"_o"))
+		}
+	}
+	if truncated {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(i18n.T(language, "Show full"), ShowFullReviewPrefix+r.ID.Hex()+"_"+mode))
+	}
+	if len(row) == 0 {
+		return nil
+	}
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &keyboard
+}
+
+// parseReviewCallback extracts the review id and the message mode from callback data of reviewKeyboard buttons.
+func parseReviewCallback(data string, prefix string) (primitive.ObjectID, bool, error) {
+	chunks := strings.SplitN(strings.TrimPrefix(data, prefix), "_", 2)
+	id, err := primitive.ObjectIDFromHex(chunks[0])
+
+	return id, len(chunks) == 2 && chunks[1] == "o", err
+}
+
+func (ctx Context) findReviewWithApp(id primitive.ObjectID) (UserReview, Application, error) {
+	var review UserReview
+	var app Application
+	err := ctx.Store.DB().Collection(collections.REVIEWS).FindOne(ctx.Store.Context, bson.M{"_id": id}).Decode(&review)
+	if err != nil {
+		return review, app, err
+	}
+	err = ctx.Store.DB().Collection(collections.APPS).FindOne(ctx.Store.Context, bson.M{"_id": review.AppId}).Decode(&app)
+
+	return review, app, err
+}
+
+// FullText returns the whole review as plain text, including the original text and the developer reply.
+func (r UserReview) FullText(settings ChatSettings) string {
+	text := r.FormatIn(settings)
+	if r.HasOriginalText() {
+		text += "\n\n" + i18n.T(settings.Language, "Original")
+		if r.Language != "" {
+			text += " (" + r.Language + ")"
+		}
+		text += ":\n" + strings.TrimSpace(r.OriginalText)
+	}
+	if r.ReplyText != "" {
+		text += "\n\n" + i18n.T(settings.Language, "Developer reply:") + "\n" + strings.TrimSpace(r.ReplyText)
+	}
+
+	return text
+}
+
+// ShowFullReview expands a truncated review, reviews too long for one message are posted as a thread of replies to it.
+type ShowFullReview struct {
+	Handler
+}
+
+func (ShowFullReview) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, ShowFullReviewPrefix) {
+		return false
+	}
+
+	reviewId, original, err := parseReviewCallback(query.Data, ShowFullReviewPrefix)
+	utils.PanicOnError(err)
+
+	review, _, err := ctx.findReviewWithApp(reviewId)
+	if err != nil {
+		_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Review not found")))
+		utils.LogError(err)
+		return true
+	}
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	utils.LogError(err)
+
+	if query.Message == nil {
+		return true
+	}
+
+	settings := ctx.ChatSettings()
+	full := review.FullText(settings)
+	keyboard := reviewKeyboard(review, original, false, settings.Language)
+
+	// the message is expanded in place when the whole review fits into it
+	if utf16Length(full) <= telegramMessageLimit {
+		edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, full)
+		edit.DisableWebPagePreview = true
+		edit.ReplyMarkup = keyboard
+		ctx.Resp <- edit
+		return true
+	}
+
+	// otherwise the full text is posted below, only the translation toggle is left on the message
+	if keyboard == nil {
+		keyboard = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+	ctx.Resp <- tgbotapi.NewEditMessageReplyMarkup(query.Message.Chat.ID, query.Message.MessageID, *keyboard)
+
+	for _, chunk := range splitUTF16(full, telegramMessageLimit) {
+		message := tgbotapi.NewMessage(query.Message.Chat.ID, chunk)
+		message.ReplyToMessageID = query.Message.MessageID
+		message.DisableWebPagePreview = true
+		ctx.Resp <- message
+	}
+
+	return true
+}
+
+func (ShowFullReview) Name() string {
+	return "ShowFullReview"
+}
+
+// ToggleTranslation switches a posted review between the translation and the original text by editing it in place.
+type ToggleTranslation struct {
+	Handler
+}
+
+func (ToggleTranslation) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, ToggleTranslationPrefix) {
+		return false
+	}
+
+	reviewId, original, err := parseReviewCallback(query.Data, ToggleTranslationPrefix)
+	utils.PanicOnError(err)
+
+	review, app, err := ctx.findReviewWithApp(reviewId)
+	if err != nil {
+		_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Review not found")))
+		utils.LogError(err)
+		return true
+	}
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	utils.LogError(err)
+
+	if query.Message == nil {
+		return true
+	}
+
+	settings := ctx.ChatSettings()
+	text, truncated := app.renderReview(review, original, settings)
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeHTML
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = reviewKeyboard(review, original, truncated, settings.Language)
+	ctx.Resp <- edit
+
+	return true
+}
+
+func (ToggleTranslation) Name() string {
+	return "ToggleTranslation"
+}
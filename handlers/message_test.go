@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTelegramLength(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello", 5},
+		{"<b>hi</b>", 2},
+		{"a &amp; b", 5},
+		{"😀", 2},
+		{"<i>😀 &lt;3</i>", 5},
+		{`<a href="https://example.com">link</a>`, 4},
+		{strings.Repeat("a", telegramMessageLimit), telegramMessageLimit},
+	}
+
+	for _, test := range tests {
+		if got := telegramLength(test.text); got != test.want {
+			t.Errorf("telegramLength(%q) = %d, want %d", test.text, got, test.want)
+		}
+	}
+}
+
+func TestTruncateUTF16(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"hello", 5, "hello"},
+		{"hello world", 8, "hello w…"},
+		{"hello world", 7, "hello…"},
+		// a surrogate pair is never cut in half
+		{"😀😀😀", 4, "😀…"},
+		{"abc", 0, ""},
+		{strings.Repeat("a", telegramMessageLimit), telegramMessageLimit, strings.Repeat("a", telegramMessageLimit)},
+		{strings.Repeat("a", telegramMessageLimit+1), telegramMessageLimit, strings.Repeat("a", telegramMessageLimit-1) + "…"},
+		{strings.Repeat("😀", telegramMessageLimit/2+1), telegramMessageLimit, strings.Repeat("😀", telegramMessageLimit/2-1) + "…"},
+	}
+
+	for _, test := range tests {
+		got := truncateUTF16(test.text, test.limit)
+		if got != test.want {
+			t.Errorf("truncateUTF16(%.20q, %d) = %.20q, want %.20q", test.text, test.limit, got, test.want)
+		}
+		if utf16Length(got) > test.limit {
+			t.Errorf("truncateUTF16(%.20q, %d) is %d long", test.text, test.limit, utf16Length(got))
+		}
+	}
+}
+
+func TestSplitUTF16SmallLimit(t *testing.T) {
+	// runes longer than the limit are put into chunks of their own instead of looping forever
+	got := splitUTF16("😀😀a", 1)
+	want := []string{"😀", "😀", "a"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitUTF16 = %q, want %q", got, want)
+	}
+}
+
+func TestSplitUTF16(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  []string
+	}{
+		{"hello", 10, []string{"hello"}},
+		{"hello world", 8, []string{"hello", "world"}},
+		{"first line\nsecond", 12, []string{"first line", "second"}},
+		{"😀😀😀", 4, []string{"😀😀", "😀"}},
+		{strings.Repeat("a", telegramMessageLimit), telegramMessageLimit, []string{strings.Repeat("a", telegramMessageLimit)}},
+		{strings.Repeat("a", telegramMessageLimit+1), telegramMessageLimit, []string{strings.Repeat("a", telegramMessageLimit), "a"}},
+		{strings.Repeat("a", 3000) + " " + strings.Repeat("b", 3000), telegramMessageLimit, []string{strings.Repeat("a", 3000), strings.Repeat("b", 3000)}},
+	}
+
+	for _, test := range tests {
+		got := splitUTF16(test.text, test.limit)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitUTF16(%.20q, %d) = %.20q, want %.20q", test.text, test.limit, got, test.want)
+		}
+		for _, chunk := range got {
+			if utf16Length(chunk) > test.limit {
+				t.Errorf("splitUTF16(%.20q, %d) has a chunk %d long", test.text, test.limit, utf16Length(chunk))
+			}
+		}
+	}
+}
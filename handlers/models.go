@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -12,6 +13,7 @@ type Application struct {
 	UserId              int                `bson:",omitempty"`
 	ID                  primitive.ObjectID `bson:"_id,omitempty"`
 	PackageName         string
+	Os                  string     `bson:",omitempty"`
 	AppStoreCountryCode string     `bson:"appStoreCountryCode,omitempty"`
 	Name                string     `bson:",omitempty"`
 	KeyFile             []byte     `bson:",omitempty"`
@@ -19,6 +21,58 @@ type Application struct {
 	LastReview          time.Time  `bson:",omitempty"`
 	LastReviewId        string     `bson:",omitempty"`
 	TranslateLanguage   string
+	Alerts              *AlertSettings `bson:",omitempty"`
+	Destinations        []Destination  `bson:",omitempty"`
+	// Template is a text/template for telegram messages, the default layout is used when empty.
+	Template string `bson:",omitempty"`
+	// Hashtags lists the enabled kinds of hashtags, see hashtagKinds.
+	Hashtags []string `bson:",omitempty"`
+	// MessageThreadId is the topic of the forum group reviews are posted to, zero for the General topic or other chats.
+	// It belongs to the app rather than to its destinations, destinations never post to telegram.
+	MessageThreadId int `bson:",omitempty"`
+	// Unreachable is set while reviews can't be posted to the chat of the app, see ChatUnreachable.
+	Unreachable *Unreachable `bson:",omitempty"`
+}
+
+// Unreachable tells why the chat of an app refuses messages, e.g. the bot was blocked, removed or lost its rights.
+type Unreachable struct {
+	Error string
+	Since time.Time
+	// Paused apps aren't checked for reviews until they are bound again, see PauseUnreachableAfter
+	Paused bool
+}
+
+// Destination is an additional place, besides the telegram chat, where reviews of an app are delivered.
+type Destination struct {
+	ID      primitive.ObjectID
+	Type    string
+	URL     string `bson:",omitempty"`
+	Token   string `bson:",omitempty"`
+	Channel string `bson:",omitempty"`
+	Secret  string `bson:",omitempty"`
+	// Address and Digest are used by email destinations, Digest is "daily" or "weekly" or empty for realtime.
+	Address    string     `bson:",omitempty"`
+	Digest     string     `bson:",omitempty"`
+	LastDigest *time.Time `bson:",omitempty"`
+}
+
+func (d Destination) Describe() string {
+	if d.Address != "" {
+		if d.Digest != "" {
+			return fmt.Sprintf("%s %s (%s digest)", d.Type, d.Address, d.Digest)
+		}
+		return fmt.Sprintf("%s %s", d.Type, d.Address)
+	}
+	if d.Channel != "" {
+		return fmt.Sprintf("%s %s", d.Type, d.Channel)
+	}
+
+	u, err := url.Parse(d.URL)
+	if err != nil || u.Host == "" {
+		return d.Type
+	}
+
+	return fmt.Sprintf("%s %s", d.Type, u.Host)
 }
 
 func (a Application) GetName() string {
@@ -29,69 +83,69 @@ func (a Application) GetName() string {
 	return a.Name
 }
 
-type Chat struct {
-	ChatId     int64              `bson:",omitempty"`
-	UserId     int                `bson:",omitempty"`
-	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	State      int                `bson:",omitempty"`
-	CustomData interface{}        `bson:",omitempty"`
+type AlertSettings struct {
+	Disabled      bool
+	MinCount      int
+	Ratio         float64
+	WindowHours   int
+	CooldownHours int
 }
 
-const (
-	ChatStateNone               = 0
-	ChatStateWaitForPackageName = 1
-	ChatStateWaitForKey         = 2
-	ChatStateWaitForApp         = 3
-	ChatStateWaitForLanguage    = 4
-	ChatStateWaitForAppName     = 5
-	ChatStateWaitForOS          = 6
-	ChatStateWaitForIosAppID    = 7
-	ChangeAppStoreWaitForCode   = 8
-)
+var DefaultAlertSettings = AlertSettings{
+	MinCount:      5,
+	Ratio:         2,
+	WindowHours:   6,
+	CooldownHours: 12,
+}
 
-func ChatStateToWaitingString(state int) string {
-	switch state {
-	case ChatStateNone:
-		return "nothing"
-	case ChatStateWaitForPackageName:
-		return "package name"
-	case ChatStateWaitForKey:
-		return "json key"
-	case ChatStateWaitForApp:
-		return "choose app"
-	case ChatStateWaitForLanguage:
-		return "language code"
-	case ChatStateWaitForAppName:
-		return "application name"
-	case ChatStateWaitForOS:
-		return "os selection"
-	case ChatStateWaitForIosAppID:
-		return "ios app id"
-	case ChangeAppStoreWaitForCode:
-		return "AppStore code"
+func (a Application) GetAlertSettings() AlertSettings {
+	if a.Alerts == nil {
+		return DefaultAlertSettings
 	}
 
-	panic(UnknownStateError{state: state})
+	return *a.Alerts
 }
 
-type UnknownStateError struct {
-	error
-	state int
+type Alert struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	AppId          primitive.ObjectID
+	ChatId         int64
+	Version        string
+	LowCount       int
+	Total          int
+	Rate           float64
+	BaselineRate   float64
+	Created        time.Time
+	AcknowledgedBy string     `bson:",omitempty"`
+	AcknowledgedAt *time.Time `bson:",omitempty"`
 }
 
-func (e UnknownStateError) Error() string {
-	return fmt.Sprintf("Cannot convert state to string: %d", e.state)
+type DeliveryLog struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	AppId       primitive.ObjectID
+	Destination string
+	ReviewId    string
+	Attempt     int
+	Error       string `bson:",omitempty"`
+	Created     time.Time
 }
 
-const (
-	ChatStateCallChangeGroupReceiver = -1
-)
+type SearchQuery struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	UserId  int
+	Query   string
+	Created time.Time
+}
 
-func ChatStateCall(state int, botUserName string, ctx Context) {
-	switch state {
-	case ChatStateCallChangeGroupReceiver:
-		ChangeGroupReceiver{
-			BotUserName: botUserName,
-		}.Handle(ctx)
-	}
+type Chat struct {
+	ChatId     int64              `bson:",omitempty"`
+	UserId     int                `bson:",omitempty"`
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	State      int                `bson:",omitempty"`
+	CustomData interface{}        `bson:",omitempty"`
+	// Conversation is the flow in progress, flows don't use State and CustomData
+	Conversation *Conversation `bson:",omitempty"`
 }
+
+// ChatStateNone is the only state left, chat states were replaced by conversations, see Flow and ResetMovedChatStates
+const ChatStateNone = 0
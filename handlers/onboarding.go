@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"google-play-review-bot/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// OnboardingPrefix starts callback data of onboarding buttons: onboarding_<page>, onboarding_newapp_<os> or onboarding_help.
+const OnboardingPrefix = "onboarding_"
+
+type onboardingButton struct {
+	Text string
+	// one of them is set: Page to open, Url to follow or Os to start /newapp with
+	Page string
+	Url  string
+	Os   string
+}
+
+type onboardingPage struct {
+	Text    string
+	Buttons [][]onboardingButton
+}
+
+// onboardingPages explain how to get what /newapp asks for, texts are english and translated when shown.
+var onboardingPages = map[string]onboardingPage{
+	"welcome": {
+		Text: "I post reviews of your Google Play and App Store apps to telegram, translate them and alert you when ratings drop.\n\n" +
+			"Which app do you want to add first?",
+		Buttons: [][]onboardingButton{
+			{{Text: "🤖 Google Play", Page: "android1"}, {Text: "🍏 App Store", Page: "ios"}},
+		},
+	},
+	"android1": {
+		Text: "Step 1 of 3: create a service account\n\n" +
+			"Open Google Cloud console, choose or create a project and create a service account in IAM & Admin → Service accounts. " +
+			"It doesn't need any roles.\n\n" +
+			"Enable Google Play Android Developer API in the same project.",
+		Buttons: [][]onboardingButton{
+			{{Text: "Service accounts", Url: "https://console.cloud.google.com/iam-admin/serviceaccounts"}},
+			{{Text: "Google Play Android Developer API", Url: "https://console.cloud.google.com/apis/library/androidpublisher.googleapis.com"}},
+			{{Text: "« Back", Page: "welcome"}, {Text: "Next »", Page: "android2"}},
+		},
+	},
+	"android2": {
+		Text: "Step 2 of 3: grant access in Play Console\n\n" +
+			"Open Users and permissions in Google Play Console and invite the email of the service account. " +
+			"Add your app to its app permissions and allow viewing app information and replying to reviews.",
+		Buttons: [][]onboardingButton{
+			{{Text: "Users and permissions", Url: "https://play.google.com/console/users-and-permissions"}},
+			{{Text: "« Back", Page: "android1"}, {Text: "Next »", Page: "android3"}},
+		},
+	},
+	"android3": {
+		Text: "Step 3 of 3: create a key\n\n" +
+			"Open the service account, go to Keys → Add key → Create new key and choose JSON. Keep the downloaded file, I will ask you to send it.\n\n" +
+			"You will also need the package name of the app, e.g. com.example.app, it's after id= in the Google Play link of the app.",
+		Buttons: [][]onboardingButton{
+			{{Text: "➕ Add Android app", Os: "android"}},
+			{{Text: "« Back", Page: "android2"}},
+		},
+	},
+	"ios": {
+		Text: "App Store reviews are public, so only the app ID is needed.\n\n" +
+			"It's the number in the App Store link of the app, e.g. 123456789 in apps.apple.com/us/app/example/id123456789, " +
+			"or Apple ID in App Information in App Store Connect.\n\n" +
+			"Reviews are read from the US store, you can change the country later in /settings.",
+		Buttons: [][]onboardingButton{
+			{{Text: "App Store Connect", Url: "https://appstoreconnect.apple.com/apps"}},
+			{{Text: "➕ Add iOS app", Os: "ios"}},
+			{{Text: "« Back", Page: "welcome"}},
+		},
+	},
+}
+
+func onboardingKeyboard(ctx Context, page onboardingPage) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, buttons := range page.Buttons {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, button := range buttons {
+			text := ctx.T(button.Text)
+			switch {
+			case button.Url != "":
+				row = append(row, tgbotapi.NewInlineKeyboardButtonURL(text, button.Url))
+			case button.Os != "":
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(text, OnboardingPrefix+"newapp_"+button.Os))
+			default:
+				row = append(row, tgbotapi.NewInlineKeyboardButtonData(text, OnboardingPrefix+button.Page))
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// startOnboarding greets users without apps with the first page of the onboarding, others get the status of their apps.
+func startOnboarding(ctx Context) {
+	apps := ctx.UserApps()
+	if len(apps) == 0 {
+		page := onboardingPages["welcome"]
+		message := tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Hello!")+"\n\n"+ctx.T(page.Text))
+		message.ReplyMarkup = onboardingKeyboard(ctx, page)
+		ctx.Resp <- message
+		return
+	}
+
+	message := tgbotapi.NewMessage(ctx.ChatId(), appsStatus(ctx, apps))
+	message.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(ctx.T("⚙️ Settings"), SettingsPrefix+"list"),
+			tgbotapi.NewInlineKeyboardButtonData(ctx.T("➕ Add app"), OnboardingPrefix+"welcome"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(ctx.T("❓ Help"), OnboardingPrefix+"help"),
+		),
+	)
+	ctx.Resp <- message
+}
+
+// appsStatus tells for every app whether reviews are coming and what is missing otherwise.
+func appsStatus(ctx Context, apps []Application) string {
+	settings := ctx.ChatSettings()
+
+	lines := []string{ctx.T("Welcome back! Your apps:")}
+	for _, app := range apps {
+		lines = append(lines, "", fmt.Sprintf("%s (%s %s)", app.GetName(), app.Os, app.PackageName))
+
+		var problems []string
+		if app.Os == "android" && len(app.KeyFile) == 0 {
+			problems = append(problems, ctx.T("⚠️ JSON key is missing, add the app again with /newapp"))
+		}
+		if app.ChatId == 0 {
+			problems = append(problems, ctx.T("⚠️ Not bound to a chat, use /changegroup"))
+		}
+		if app.Unreachable != nil {
+			problems = append(problems, ctx.T("⚠️ Can't post reviews: %s", app.Unreachable.Error), ctx.T("Fix it and use /rebind"))
+		}
+		if len(problems) > 0 {
+			lines = append(lines, problems...)
+			continue
+		}
+
+		if app.ChatId == ctx.ChatId() {
+			lines = append(lines, ctx.T("✅ Reviews are posted here"))
+		} else {
+			lines = append(lines, ctx.T("✅ Reviews are posted to another chat"))
+		}
+		if app.LastReviewsQueried != nil {
+			lines = append(lines, ctx.T("Checked for reviews: %s", settings.FormatTime(*app.LastReviewsQueried)))
+		}
+		if !app.LastReview.IsZero() {
+			lines = append(lines, ctx.T("Latest review: %s", settings.FormatTime(app.LastReview)))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Onboarding handles the buttons of onboarding pages, editing the message to show the next page.
+type Onboarding struct {
+	Handler
+}
+
+func (Onboarding) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || query.Message == nil || !strings.HasPrefix(query.Data, OnboardingPrefix) {
+		return false
+	}
+
+	_, err := ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	utils.LogError(err)
+
+	action := strings.TrimPrefix(query.Data, OnboardingPrefix)
+	switch {
+	case action == "help":
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), helpText(ctx))
+	case strings.HasPrefix(action, "newapp_"):
+		// the button starts the same flow as /newapp, with the same restrictions
+		os := strings.TrimPrefix(action, "newapp_")
+		if os != "android" && os != "ios" {
+			return true
+		}
+		if ctx.ensureChatAdmin("newapp") {
+			flows["NewApp"].StartWith(ctx, bson.M{"os": os})
+		}
+	default:
+		page, ok := onboardingPages[action]
+		if !ok {
+			return true
+		}
+		keyboard := onboardingKeyboard(ctx, page)
+		edit := tgbotapi.NewEditMessageText(ctx.ChatId(), query.Message.MessageID, ctx.T(page.Text))
+		edit.ReplyMarkup = &keyboard
+		edit.DisableWebPagePreview = true
+		ctx.Resp <- edit
+	}
+
+	return true
+}
+
+func (Onboarding) Name() string {
+	return "Onboarding"
+}
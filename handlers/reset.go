@@ -9,16 +9,13 @@ type Reset struct {
 }
 
 func (Reset) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/reset") {
-		return false
-	}
-
 	err := ctx.ChangeChatState(ChatStateNone)
 
 	if err != nil {
 		panic(err)
 	}
-	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "State reset")
+	ctx.CancelConversation()
+	ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("State reset"))
 	return true
 }
 
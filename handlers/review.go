@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserReview struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	AppId          primitive.ObjectID
+	ReviewId       string
+	UserName       string
+	Device         string
+	Manufacturer   string `bson:",omitempty"`
+	SdkInt         int
+	Text           string
+	Time           time.Time
+	Fetched        time.Time
+	Rating         int
+	AppVersion     string
+	AppBuildNumber int64
+	AppName        string
+	OriginalText   string     `bson:",omitempty"`
+	Language       string     `bson:",omitempty"`
+	ReplyText      string     `bson:",omitempty"`
+	ReplyTime      *time.Time `bson:",omitempty"`
+	Imported       bool       `bson:",omitempty"`
+	Url            string     `bson:",omitempty"`
+	Country        string     `bson:",omitempty"`
+	Edited         bool       `bson:"-"`
+	ChatId         int64      `bson:",omitempty"`
+	MessageId      int        `bson:",omitempty"`
+}
+
+// ChangedFrom tells whether the user edited the stored review or the developer replied, refetches of the same review,
+// e.g. to translate it into another language, don't count.
+func (r UserReview) ChangedFrom(stored UserReview) bool {
+	return r.authoredText() != stored.authoredText() || r.Rating != stored.Rating || r.ReplyText != stored.ReplyText
+}
+
+func (r UserReview) authoredText() string {
+	if r.OriginalText != "" {
+		return r.OriginalText
+	}
+
+	return r.Text
+}
+
+// Link returns a link to the message the review was posted as, if telegram allows linking to that chat.
+func (r UserReview) Link() string {
+	if r.MessageId == 0 || r.ChatId > -1000000000000 {
+		return ""
+	}
+
+	return fmt.Sprintf("https://t.me/c/%d/%d", -r.ChatId-1000000000000, r.MessageId)
+}
+
+// FetchedOrTime returns the moment the review appeared for the bot, which is what rolling windows are based on.
+func (r UserReview) FetchedOrTime() time.Time {
+	if r.Fetched.IsZero() {
+		return r.Time
+	}
+
+	return r.Fetched
+}
+
+// reviewTimeFilter matches reviews written in the period, reviews stored without time, like older ones of iOS apps,
+// match by the time they were fetched.
+func reviewTimeFilter(period bson.M) bson.A {
+	return bson.A{
+		bson.M{"time": period},
+		bson.M{"time": time.Time{}, "fetched": period},
+	}
+}
+
+// sortByReviewTime returns the aggregation stages matching the filter and sorting by the time the review was written,
+// falling back to the time it was fetched the same way as reviewTimeFilter.
+func sortByReviewTime(filter bson.M, order int) bson.A {
+	return bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$addFields": bson.M{
+			"sorttime": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$time", time.Time{}}}, "$fetched", "$time"}},
+		}},
+		bson.M{"$sort": bson.M{"sorttime": order, "_id": order}},
+	}
+}
+
+func (r UserReview) Format() string {
+	return r.FormatIn(ChatSettings{})
+}
+
+// FormatIn renders the review as plain text with timestamps in the timezone and date format of the chat.
+func (r UserReview) FormatIn(settings ChatSettings) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(r.HeaderIn(settings))
+	buffer.WriteString("\n")
+
+	if len(r.UserName) > 0 {
+		buffer.WriteString(r.UserName)
+		buffer.WriteString("\n")
+	}
+
+	if device := r.DeviceDescription(); device != "" {
+		buffer.WriteString(device)
+		buffer.WriteString("\n")
+	}
+
+	buffer.WriteString(r.RatingIcons())
+
+	if len(r.Text) > 0 {
+		buffer.WriteString("\n")
+		buffer.WriteString(strings.TrimSpace(r.Text))
+	}
+
+	return buffer.String()
+}
+
+// FormatHTML renders the review with the default template for telegram HTML parse mode.
+func (r UserReview) FormatHTML() string {
+	text, err := executeReviewTemplate(defaultReviewTemplate, newReviewTemplateData(r, nil, ChatSettings{}))
+	if err != nil {
+		return html.EscapeString(r.Format())
+	}
+
+	return text
+}
+
+// HasOriginalText tells whether the text was translated, so the original differs from it.
+func (r UserReview) HasOriginalText() bool {
+	return r.OriginalText != "" && strings.TrimSpace(r.OriginalText) != strings.TrimSpace(r.Text)
+}
+
+// CountryCode returns the country of the store the review was left in, falling back to the region of the reviewer language.
+func (r UserReview) CountryCode() string {
+	if r.Country != "" {
+		return r.Country
+	}
+
+	if i := strings.IndexAny(r.Language, "_-"); i >= 0 {
+		return strings.ToUpper(r.Language[i+1:])
+	}
+
+	return ""
+}
+
+func (r UserReview) Header() string {
+	return r.HeaderIn(ChatSettings{})
+}
+
+func (r UserReview) HeaderIn(settings ChatSettings) string {
+	if r.Time.IsZero() {
+		return fmt.Sprintf("%s %s",
+			r.AppName,
+			r.AppVersion,
+		)
+	}
+
+	timeFormatted := settings.FormatTime(r.Time)
+
+	return fmt.Sprintf("%s %s (%d) at %s",
+		r.AppName,
+		r.AppVersion,
+		r.AppBuildNumber,
+		timeFormatted,
+	)
+}
+
+func (r UserReview) DeviceDescription() string {
+	var buffer bytes.Buffer
+
+	if r.Device != "" {
+		buffer.WriteString("Device: ")
+		buffer.WriteString(r.Device)
+	}
+	if r.SdkInt > 0 {
+		buffer.WriteString(" on Android ")
+		buffer.WriteString(sdkIntToString(r.SdkInt))
+	}
+
+	return buffer.String()
+}
+
+func (r UserReview) RatingIcons() string {
+	hearticon := "💔"
+	if r.Rating > 3 {
+		hearticon = "❤️"
+	}
+
+	return strings.Repeat(hearticon, r.Rating)
+}
+
+func sdkIntToString(sdkInt int) string {
+	switch sdkInt {
+	case 36:
+		return "16"
+	case 35:
+		return "15"
+	case 34:
+		return "14"
+	case 33:
+		return "13"
+	case 32:
+		return "12L"
+	case 31:
+		return "12"
+	case 30:
+		return "11"
+	case 29:
+		return "10"
+	case 28:
+		return "9"
+	case 27:
+		return "8.1"
+	case 26:
+		return "8.0"
+	case 25:
+		return "7.1"
+	case 24:
+		return "7.0"
+	case 23:
+		return "6"
+	case 22:
+		return "5.1"
+	case 21:
+		return "5.0"
+	case 20:
+		return "4.4W o_O"
+	case 19:
+		return "4.4"
+	case 18:
+		return "4.3"
+	case 17:
+		return "4.2"
+	case 16:
+		return "4.1"
+	case 15:
+		return "4.0.4"
+	case 14:
+		return "4.0"
+	default:
+		return fmt.Sprintf("Unknown (%d)", sdkInt)
+	}
+}
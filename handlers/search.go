@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+const (
+	SearchPagePrefix    = "search_"
+	searchPageSize      = 5
+	searchReviewLength  = 500
+	searchDateFormat    = "2006-01-02"
+	searchUsageResponse = "Usage: /search <words> [app:<name>] [rating:<1-5 or 1-2>] [version:<name>] [from:yyyy-mm-dd] [to:yyyy-mm-dd] [device:<name>]"
+)
+
+type Search struct {
+	Handler
+}
+
+func (Search) Handle(ctx Context) bool {
+	fields := strings.Fields(ctx.Update.Message.Text)
+	query := strings.Join(fields[1:], " ")
+	if query == "" {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(searchUsageResponse))
+		return true
+	}
+
+	filter, err := makeSearchFilter(query, ctx.UserApps())
+	if err != nil {
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.Localize(err)+"\n"+ctx.T(searchUsageResponse))
+		return true
+	}
+
+	search := SearchQuery{
+		UserId:  ctx.UserId(),
+		Query:   query,
+		Created: time.Now(),
+	}
+	res, err := ctx.Store.DB().Collection(collections.SEARCHES).InsertOne(ctx.Store.Context, search)
+	utils.PanicOnError(err)
+	search.ID = res.InsertedID.(primitive.ObjectID)
+
+	text, markup := renderSearchPage(ctx, search, filter, 0)
+	message := tgbotapi.NewMessage(ctx.ChatId(), text)
+	message.DisableWebPagePreview = true
+	if markup != nil {
+		message.ReplyMarkup = *markup
+	}
+	ctx.Resp <- message
+
+	return true
+}
+
+func (Search) Name() string {
+	return "Search"
+}
+
+type SearchPage struct {
+	Handler
+}
+
+func (SearchPage) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || !strings.HasPrefix(query.Data, SearchPagePrefix) {
+		return false
+	}
+
+	chunks := strings.Split(strings.TrimPrefix(query.Data, SearchPagePrefix), "_")
+	if len(chunks) != 2 {
+		return true
+	}
+	searchId, err := primitive.ObjectIDFromHex(chunks[0])
+	utils.PanicOnError(err)
+	page, err := strconv.Atoi(chunks[1])
+	utils.PanicOnError(err)
+
+	var search SearchQuery
+	err = ctx.Store.DB().Collection(collections.SEARCHES).FindOne(ctx.Store.Context, bson.M{
+		"_id":    searchId,
+		"userid": ctx.UserId(),
+	}).Decode(&search)
+	if err != nil {
+		_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Search expired, please repeat it")))
+		utils.LogError(err)
+		return true
+	}
+
+	filter, err := makeSearchFilter(search.Query, ctx.UserApps())
+	utils.PanicOnError(err)
+
+	text, markup := renderSearchPage(ctx, search, filter, page)
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, text)
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = markup
+	ctx.Resp <- edit
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	utils.LogError(err)
+
+	return true
+}
+
+func (SearchPage) Name() string {
+	return "SearchPage"
+}
+
+var ratingRangeRegexp = regexp.MustCompile(`^([1-5])(?:-([1-5]))?$`)
+
+func makeSearchFilter(query string, apps []Application) (bson.M, error) {
+	var words []string
+	appIds := []primitive.ObjectID{}
+	for _, app := range apps {
+		appIds = append(appIds, app.ID)
+	}
+	filter := bson.M{}
+	period := bson.M{}
+
+	for _, field := range strings.Fields(query) {
+		chunks := strings.SplitN(field, ":", 2)
+		if len(chunks) != 2 || chunks[1] == "" {
+			words = append(words, field)
+			continue
+		}
+
+		key, value := strings.ToLower(chunks[0]), chunks[1]
+		switch key {
+		case "app":
+			appIds = nil
+			for _, app := range apps {
+				if strings.EqualFold(app.GetName(), value) || strings.EqualFold(app.PackageName, value) {
+					appIds = append(appIds, app.ID)
+				}
+			}
+			if len(appIds) == 0 {
+				return nil, i18n.Errorf("Unknown app: %s", value)
+			}
+		case "rating":
+			match := ratingRangeRegexp.FindStringSubmatch(value)
+			if match == nil {
+				return nil, i18n.Errorf("Invalid rating: %s", value)
+			}
+			from, _ := strconv.Atoi(match[1])
+			to := from
+			if match[2] != "" {
+				to, _ = strconv.Atoi(match[2])
+			}
+			filter["rating"] = bson.M{"$gte": from, "$lte": to}
+		case "version":
+			filter["appversion"] = value
+		case "device":
+			filter["device"] = primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+		case "from", "to":
+			date, err := time.Parse(searchDateFormat, value)
+			if err != nil {
+				return nil, i18n.Errorf("Invalid date: %s", value)
+			}
+			if key == "from" {
+				period["$gte"] = date
+			} else {
+				period["$lt"] = date.Add(24 * time.Hour)
+			}
+		default:
+			words = append(words, field)
+		}
+	}
+
+	filter["appid"] = bson.M{"$in": appIds}
+	if len(period) > 0 {
+		filter["$or"] = reviewTimeFilter(period)
+	}
+	if len(words) > 0 {
+		filter["$text"] = bson.M{"$search": strings.Join(words, " ")}
+	}
+
+	return filter, nil
+}
+
+func renderSearchPage(ctx Context, search SearchQuery, filter bson.M, page int) (string, *tgbotapi.InlineKeyboardMarkup) {
+	collection := ctx.Store.DB().Collection(collections.REVIEWS)
+
+	total, err := collection.CountDocuments(ctx.Store.Context, filter)
+	utils.PanicOnError(err)
+	if total == 0 {
+		return ctx.T("Nothing found for: %s", search.Query), nil
+	}
+
+	pages := int((total + searchPageSize - 1) / searchPageSize)
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	pipeline := append(sortByReviewTime(filter, -1),
+		bson.M{"$skip": page * searchPageSize},
+		bson.M{"$limit": searchPageSize})
+	c, err := collection.Aggregate(ctx.Store.Context, pipeline)
+	utils.PanicOnError(err)
+
+	var reviews []UserReview
+	err = c.All(ctx.Store.Context, &reviews)
+	utils.PanicOnError(err)
+
+	settings := ctx.ChatSettings()
+	var buffer bytes.Buffer
+	buffer.WriteString(ctx.T("Found %d reviews for: %s (page %d/%d)", total, search.Query, page+1, pages) + "\n")
+	for _, r := range reviews {
+		buffer.WriteString("\n")
+		buffer.WriteString(truncate(r.FormatIn(settings), searchReviewLength))
+		if link := r.Link(); link != "" {
+			buffer.WriteString("\n")
+			buffer.WriteString(link)
+		}
+		buffer.WriteString("\n")
+	}
+
+	var buttons []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(ctx.T("◀️ Prev"),
+			fmt.Sprintf("%s%s_%d", SearchPagePrefix, search.ID.Hex(), page-1)))
+	}
+	if page < pages-1 {
+		buttons = append(buttons, tgbotapi.NewInlineKeyboardButtonData(ctx.T("Next ▶️"),
+			fmt.Sprintf("%s%s_%d", SearchPagePrefix, search.ID.Hex(), page+1)))
+	}
+	if len(buttons) == 0 {
+		return buffer.String(), nil
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(buttons)
+	return buffer.String(), &markup
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length]) + "…"
+}
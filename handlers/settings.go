@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/utils"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// SettingsPrefix starts callback data of the settings menu: settings_<app id>_<option>_<value>, or settings_list.
+const SettingsPrefix = "settings_"
+
+// settingsLanguages are offered as buttons for translation, others can be typed in.
+var settingsLanguages = []string{"en", "de", "es", "fr", "it", "pt", "ru", "uk", "ja", "zh"}
+
+// Settings shows the apps of the user, each leads to a menu of its settings which is navigated by editing the message.
+type Settings struct {
+	Handler
+}
+
+func (Settings) Handle(ctx Context) bool {
+	text, keyboard := settingsAppList(ctx)
+	message := tgbotapi.NewMessage(ctx.ChatId(), text)
+	if keyboard != nil {
+		message.ReplyMarkup = *keyboard
+	}
+	ctx.Resp <- message
+
+	return true
+}
+
+func (Settings) Name() string {
+	return "Settings"
+}
+
+func settingsAppList(ctx Context) (string, *tgbotapi.InlineKeyboardMarkup) {
+	apps := ctx.UserApps()
+	if len(apps) == 0 {
+		return ctx.T("You have no configured apps yet. /newapp ?"), nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, app := range apps {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(app.GetName(), SettingsPrefix+app.ID.Hex())))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+
+	return ctx.T("Please choose app"), &keyboard
+}
+
+func settingsButton(text string, app Application, option ...string) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData(text, strings.Join(append([]string{SettingsPrefix + app.ID.Hex()}, option...), "_"))
+}
+
+func settingsBackButton(ctx Context, app Application) tgbotapi.InlineKeyboardButton {
+	return settingsButton(ctx.T("« Back"), app)
+}
+
+// settingsMenu describes the app and offers a button for every option.
+func settingsMenu(ctx Context, app Application) (string, tgbotapi.InlineKeyboardMarkup) {
+	lines := []string{
+		fmt.Sprintf("%s (%s %s)", app.GetName(), app.Os, app.PackageName),
+		ctx.T("Translation language: %s", app.TranslateLanguage),
+	}
+	if app.Os == "ios" {
+		lines = append(lines, ctx.T("AppStore country: %s", app.AppStoreCountryCode))
+	}
+
+	switch app.ChatId {
+	case 0:
+		lines = append(lines, ctx.T("Chat: not bound yet"))
+	case ctx.ChatId():
+		lines = append(lines, ctx.T("Chat: this one"))
+	default:
+		lines = append(lines, ctx.T("Chat: another one"))
+	}
+	if app.MessageThreadId != 0 {
+		lines = append(lines, ctx.T("Topic: its own"))
+	}
+	if app.Unreachable != nil {
+		lines = append(lines, ctx.T("⚠️ Can't post reviews: %s", app.Unreachable.Error))
+		if app.Unreachable.Paused {
+			lines = append(lines, ctx.T("⏸ Checking for reviews is paused"))
+		}
+	}
+
+	alerts := app.GetAlertSettings()
+	if alerts.Disabled {
+		lines = append(lines, ctx.T("Alerts: off"))
+	} else {
+		lines = append(lines, ctx.T("Alerts: %d low ratings, %g times the usual rate in %d hours, then a pause of %d hours",
+			alerts.MinCount, alerts.Ratio, alerts.WindowHours, alerts.CooldownHours))
+	}
+
+	if app.Template == "" {
+		lines = append(lines, ctx.T("Template: default"))
+	} else {
+		lines = append(lines, ctx.T("Template: custom"))
+	}
+
+	if len(app.Hashtags) == 0 {
+		lines = append(lines, ctx.T("Hashtags: off"))
+	} else {
+		lines = append(lines, ctx.T("Hashtags: %s", strings.Join(app.Hashtags, ", ")))
+	}
+
+	lines = append(lines, ctx.T("Destinations: %d", len(app.Destinations)))
+
+	alertsToggle := ctx.T("🔔 Turn alerts off")
+	if alerts.Disabled {
+		alertsToggle = ctx.T("🔕 Turn alerts on")
+	}
+
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("✏️ Name"), app, "name"),
+			settingsButton(ctx.T("🌐 Translation"), app, "lang"),
+		),
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		settingsButton(ctx.T("💬 Chat"), app, "group"),
+		settingsButton(ctx.T("📢 Channel"), app, "channel"),
+	))
+	if app.Unreachable != nil {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("🔁 Rebind"), app, "rebind"),
+		))
+	}
+	if app.Os == "ios" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("🍏 AppStore"), app, "store"),
+		))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			settingsButton(alertsToggle, app, "alerts"),
+			settingsButton(ctx.T("⚙️ Alert thresholds"), app, "thresholds"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("#️⃣ Hashtags"), app, "tags"),
+			settingsButton(ctx.T("📝 Template"), app, "template"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("➕ Destination"), app, "destination"),
+			settingsButton(ctx.T("📬 Deliveries"), app, "deliveries"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			settingsButton(ctx.T("📤 Export"), app, "export"),
+		),
+	)
+	// only groups have topics
+	if app.ChatId < 0 {
+		topicToggle := ctx.T("🧵 Own topic")
+		if app.MessageThreadId != 0 {
+			topicToggle = ctx.T("🧵 Post to General")
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(settingsButton(topicToggle, app, "topic")))
+	}
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(ctx.T("« Apps"), SettingsPrefix+"list"),
+		),
+	)
+
+	return strings.Join(lines, "\n"), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func settingsLanguageMenu(ctx Context, app Application) (string, tgbotapi.InlineKeyboardMarkup) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, language := range settingsLanguages {
+		text := language
+		if language == app.TranslateLanguage {
+			text = "✅ " + text
+		}
+		row = append(row, settingsButton(text, app, "lang", language))
+		if len(row) == 5 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		settingsButton(ctx.T("Other…"), app, "lang", "other"),
+		settingsBackButton(ctx, app),
+	))
+
+	return ctx.T("Translate reviews of %s to:", app.GetName()), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func settingsHashtagsMenu(ctx Context, app Application) (string, tgbotapi.InlineKeyboardMarkup) {
+	enabled := map[string]bool{}
+	for _, kind := range app.Hashtags {
+		enabled[kind] = true
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, kind := range hashtagKinds {
+		text := "▫️ " + kind
+		if enabled[kind] {
+			text = "✅ " + kind
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(settingsButton(text, app, "tags", kind)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(settingsBackButton(ctx, app)))
+
+	return ctx.T("Tap hashtags to turn them on or off for %s", app.GetName()), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (ctx Context) findUserApp(id primitive.ObjectID) (Application, error) {
+	var app Application
+	err := ctx.Store.DB().Collection(collections.APPS).FindOne(ctx.Store.Context, bson.M{
+		"_id":    id,
+		"userid": ctx.UserId(),
+	}).Decode(&app)
+
+	return app, err
+}
+
+func (ctx Context) editSettings(messageId int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.NewEditMessageText(ctx.ChatId(), messageId, text)
+	edit.ReplyMarkup = keyboard
+	ctx.Resp <- edit
+}
+
+// refreshSettingsMenu shows the current settings of the app in the menu message, after they were changed.
+func (ctx Context) refreshSettingsMenu(messageId int, appId primitive.ObjectID) {
+	app, err := ctx.findUserApp(appId)
+	if err != nil {
+		utils.LogError(err)
+		return
+	}
+
+	text, keyboard := settingsMenu(ctx, app)
+	ctx.editSettings(messageId, text, &keyboard)
+}
+
+// SettingsMenu handles the buttons of the settings menu, options which need typed input continue with their flows.
+type SettingsMenu struct {
+	Handler
+}
+
+func (SettingsMenu) Handle(ctx Context) bool {
+	query := ctx.Update.CallbackQuery
+	if query == nil || query.Message == nil || !strings.HasPrefix(query.Data, SettingsPrefix) {
+		return false
+	}
+	if !ctx.IsChatAdmin() {
+		_, err := ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("Only admins of this group can change settings")))
+		utils.LogError(err)
+		return true
+	}
+	messageId := query.Message.MessageID
+
+	chunks := strings.SplitN(strings.TrimPrefix(query.Data, SettingsPrefix), "_", 3)
+	if chunks[0] == "list" {
+		_, err := ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+		utils.LogError(err)
+
+		text, keyboard := settingsAppList(ctx)
+		ctx.editSettings(messageId, text, keyboard)
+		return true
+	}
+
+	appId, err := primitive.ObjectIDFromHex(chunks[0])
+	utils.PanicOnError(err)
+
+	app, err := ctx.findUserApp(appId)
+	if err != nil {
+		_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ctx.T("App not found")))
+		utils.LogError(err)
+		return true
+	}
+
+	_, err = ctx.Bot.AnswerCallbackQuery(tgbotapi.NewCallback(query.ID, ""))
+	utils.LogError(err)
+
+	option, value := "", ""
+	if len(chunks) > 1 {
+		option = chunks[1]
+	}
+	if len(chunks) > 2 {
+		value = chunks[2]
+	}
+
+	switch option {
+	case "lang":
+		switch value {
+		case "":
+			text, keyboard := settingsLanguageMenu(ctx, app)
+			ctx.editSettings(messageId, text, &keyboard)
+			return true
+		case "other":
+			flows["ChangeLanguage"].StartForApp(ctx, app.ID, messageId)
+			return true
+		}
+		ctx.updateAppRefetchingReviews(app.ID, bson.M{"translatelanguage": value})
+		ctx.AppChanges <- 1
+	case "name":
+		flows["ChangeAppName"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "store":
+		flows["ChangeAppStore"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "group":
+		flows["ChangeGroup"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "channel":
+		flows["ChangeChannel"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "rebind":
+		flows["Rebind"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "alerts":
+		alerts := app.GetAlertSettings()
+		alerts.Disabled = !alerts.Disabled
+		ctx.updateApp(app.ID, bson.M{"alerts": alerts})
+	case "topic":
+		if app.MessageThreadId != 0 {
+			ctx.updateApp(app.ID, bson.M{"messagethreadid": 0})
+			ctx.AppChanges <- 1
+		} else if err := ctx.createAppTopic(app.ID); err != nil {
+			log.Printf("[SettingsMenu] createAppTopic: %s", err)
+			ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+				"Couldn't create a topic: %s\nThe chat has to be a forum and I need the right to manage topics", err))
+		}
+	case "thresholds":
+		flows["ChangeAlerts"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "tags":
+		if value != "" {
+			ctx.updateApp(app.ID, bson.M{"hashtags": toggleHashtag(app.Hashtags, value)})
+			app, err = ctx.findUserApp(app.ID)
+			utils.PanicOnError(err)
+		}
+		text, keyboard := settingsHashtagsMenu(ctx, app)
+		ctx.editSettings(messageId, text, &keyboard)
+		return true
+	case "template":
+		flows["ChangeTemplate"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "destination":
+		flows["AddDestination"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "deliveries":
+		flows["ShowDeliveries"].StartForApp(ctx, app.ID, messageId)
+		return true
+	case "export":
+		flows["Export"].StartForApp(ctx, app.ID, messageId)
+		return true
+	}
+
+	ctx.refreshSettingsMenu(messageId, app.ID)
+
+	return true
+}
+
+func (SettingsMenu) Name() string {
+	return "SettingsMenu"
+}
+
+func (ctx Context) updateApp(appId primitive.ObjectID, update bson.M) {
+	_, err := ctx.Store.DB().Collection(collections.APPS).UpdateOne(ctx.Store.Context, bson.M{"_id": appId}, bson.M{
+		"$set": update,
+	})
+	utils.PanicOnError(err)
+}
+
+func toggleHashtag(enabled []string, kind string) []string {
+	on := map[string]bool{}
+	for _, k := range enabled {
+		on[k] = true
+	}
+	on[kind] = !on[kind]
+
+	kinds := []string{}
+	for _, k := range hashtagKinds {
+		if on[k] {
+			kinds = append(kinds, k)
+		}
+	}
+
+	return kinds
+}
@@ -13,18 +13,19 @@ type StartHandler struct {
 }
 
 func (StartHandler) Handle(ctx Context) bool {
-	if !ctx.EnsureCommand("/start") {
-		return false
-	}
-
 	chunks := strings.Split(ctx.Update.Message.Text, " ")
 	if len(chunks) > 1 {
 		id, err := primitive.ObjectIDFromHex(chunks[1])
 		utils.PanicOnError(err)
 
 		ctx.BindAppToChatId(id, ctx.ChatId())
+	} else if ctx.IsPrivateChat() && ctx.activeConversation() != nil {
+		// a conversation moved here from a group before the user ever talked to the bot
+		ctx.answerWaiting(ctx.loadChatState())
+	} else if ctx.IsPrivateChat() {
+		startOnboarding(ctx)
 	} else {
-		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), "Hello!")
+		ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Hello!")+"\n\n"+helpText(ctx))
 	}
 
 	return true
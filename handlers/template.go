@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"html"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// DefaultReviewTemplate is used by apps without their own template, the output is sent in telegram HTML parse mode.
+const DefaultReviewTemplate = `<b>{{.Header}}</b>
+{{- if .UserName}}
+<i>{{.UserName}}</i>
+{{- end}}
+{{- if .Device}}
+{{.Device}}
+{{- end}}
+{{.Stars}}
+{{- if .Text}}
+{{.Text}}
+{{- end}}
+{{- if .OriginalText}}
+<blockquote expandable>{{if .Language}}{{.Language}}: {{end}}{{.OriginalText}}</blockquote>
+{{- end}}
+{{- if .Url}}
+<a href="{{.Url}}">{{.OpenReview}}</a>
+{{- end}}
+{{- if .Hashtags}}
+{{.Hashtags}}
+{{- end}}`
+
+const templateFieldsHelp = `Fields: {{.Header}} {{.App}} {{.Version}} {{.Build}} {{.Time}} {{.UserName}} {{.Device}} {{.DeviceName}} {{.Os}} {{.Rating}} {{.Stars}} {{.Text}} {{.OriginalText}} {{.Language}} {{.Url}} {{.OpenReview}} {{.Hashtags}}
+Functions: hashtag, e.g. #v{{hashtag .Version}}
+HTML tags supported by telegram may be used, field values are already escaped.`
+
+var defaultReviewTemplate = template.Must(ParseReviewTemplate(DefaultReviewTemplate))
+
+var hashtagRegexp = regexp.MustCompile(`[^\pL\pN_]+`)
+
+var reviewTemplateFuncs = template.FuncMap{
+	"hashtag": hashtag,
+}
+
+// sampleReview is rendered when a template is saved, so mistakes show up before real reviews are affected.
+var sampleReview = UserReview{
+	ReviewId:       "sample",
+	UserName:       "Jane <Doe>",
+	Device:         "Pixel 7",
+	Manufacturer:   "Google",
+	SdkInt:         34,
+	Text:           "Great app, but it crashes when I rotate the screen & lose my draft",
+	Time:           time.Date(2024, 5, 3, 14, 30, 0, 0, time.UTC),
+	Rating:         4,
+	AppVersion:     "5.3.0",
+	AppBuildNumber: 530,
+	AppName:        "Example App",
+	OriginalText:   "Tolle App, aber sie stürzt ab, wenn ich den Bildschirm drehe & verliere meinen Entwurf",
+	Language:       "de_DE",
+	Url:            "https://play.google.com/store/apps/details?id=com.example.app",
+}
+
+type reviewTemplateData struct {
+	Header       string
+	App          string
+	Version      string
+	Build        int64
+	Time         string
+	UserName     string
+	Device       string
+	DeviceName   string
+	Os           string
+	Rating       int
+	Stars        string
+	Text         string
+	OriginalText string
+	Language     string
+	Url          string
+	// OpenReview is the text of the link to the review in the language of the chat
+	OpenReview string
+	Hashtags   string
+}
+
+func newReviewTemplateData(r UserReview, hashtags []string, settings ChatSettings) reviewTemplateData {
+	data := reviewTemplateData{
+		Header:     html.EscapeString(r.HeaderIn(settings)),
+		App:        html.EscapeString(r.AppName),
+		Version:    html.EscapeString(r.AppVersion),
+		Build:      r.AppBuildNumber,
+		UserName:   html.EscapeString(r.UserName),
+		Device:     html.EscapeString(r.DeviceDescription()),
+		DeviceName: html.EscapeString(r.Device),
+		Rating:     r.Rating,
+		Stars:      r.RatingIcons(),
+		Text:       html.EscapeString(strings.TrimSpace(r.Text)),
+		Language:   html.EscapeString(r.Language),
+		Url:        html.EscapeString(r.Url),
+		OpenReview: html.EscapeString(i18n.T(settings.Language, "Open review")),
+		Hashtags:   strings.Join(r.Hashtags(hashtags), " "),
+	}
+	if !r.Time.IsZero() {
+		data.Time = html.EscapeString(settings.FormatTime(r.Time))
+	}
+	if r.SdkInt > 0 {
+		data.Os = "Android " + sdkIntToString(r.SdkInt)
+	}
+	if r.HasOriginalText() {
+		data.OriginalText = html.EscapeString(strings.TrimSpace(r.OriginalText))
+	}
+
+	return data
+}
+
+func ParseReviewTemplate(text string) (*template.Template, error) {
+	return template.New("review").Funcs(reviewTemplateFuncs).Parse(text)
+}
+
+// FormatReview renders the review with the template and hashtags of the app, falling back to the default template if it is broken.
+func (a Application) FormatReview(r UserReview, settings ChatSettings) string {
+	t := defaultReviewTemplate
+	if a.Template != "" {
+		custom, err := ParseReviewTemplate(a.Template)
+		if err == nil {
+			t = custom
+		}
+		utils.LogError(err)
+	}
+
+	data := newReviewTemplateData(r, a.Hashtags, settings)
+	text, err := executeReviewTemplate(t, data)
+	if err != nil && t != defaultReviewTemplate {
+		utils.LogError(err)
+		text, err = executeReviewTemplate(defaultReviewTemplate, data)
+	}
+	if err != nil {
+		return html.EscapeString(r.FormatIn(settings))
+	}
+
+	return text
+}
+
+func executeReviewTemplate(t *template.Template, data reviewTemplateData) (string, error) {
+	var buffer bytes.Buffer
+	if err := t.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+
+	text := strings.TrimSpace(buffer.String())
+	if text == "" {
+		return "", i18n.Errorf("template produced an empty message")
+	}
+
+	// hashtags are enabled in /settings, so they are added to templates which don't place them
+	if data.Hashtags != "" && !usesHashtags(t) {
+		text += "\n" + data.Hashtags
+	}
+
+	return text, nil
+}
+
+func usesHashtags(t *template.Template) bool {
+	return t.Tree != nil && strings.Contains(t.Tree.Root.String(), ".Hashtags")
+}
+
+// ChangeTemplateFlow validates the template against the sample review and saves it once telegram accepts the preview.
+func ChangeTemplateFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "ChangeTemplate",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+			{
+				Name:    "template",
+				Waiting: "message template, or default",
+				Prompt: func(ctx Context, c *Conversation) tgbotapi.Chattable {
+					return tgbotapi.NewMessage(ctx.ChatId(), ctx.T("Send a template for review messages, or default to reset it. The default one is:")+
+						"\n\n"+DefaultReviewTemplate+"\n\n"+ctx.T(templateFieldsHelp))
+				},
+				Receive: receiveTemplate,
+			},
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			appId := c.AppId()
+			text := c.String("template")
+			chatId := ctx.ChatId()
+			resp := ctx.Resp
+
+			message := tgbotapi.NewMessage(chatId, c.String("preview"))
+			message.ParseMode = tgbotapi.ModeHTML
+			message.DisableWebPagePreview = true
+			ctx.Resp <- TrackedMessage{
+				Chattable: message,
+				OnSent: func(tgbotapi.Message) {
+					datastore.Use(func(store *datastore.Datastore) {
+						_, err := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{"_id": appId}, bson.M{
+							"$set": bson.M{"template": text},
+						})
+						utils.LogError(err)
+					})
+					resp <- tgbotapi.NewMessage(chatId, ctx.T("Template saved, above is a preview"))
+				},
+				OnFailed: func(err error) {
+					resp <- tgbotapi.NewMessage(chatId, ctx.T("Telegram rejected the template, it wasn't saved: %s", ctx.Localize(err)))
+				},
+			}
+		},
+	})
+}
+
+// receiveTemplate renders the sample review with the template, an empty template resets it to the default one.
+func receiveTemplate(ctx Context, c *Conversation) (interface{}, error) {
+	text := strings.TrimSpace(ctx.Update.Message.Text)
+	if strings.EqualFold(text, "default") {
+		text = ""
+	}
+
+	app, err := ctx.findUserApp(c.AppId())
+	if err != nil {
+		return nil, err
+	}
+
+	settings := ctx.ChatSettings()
+	preview := Application{Hashtags: app.Hashtags}.FormatReview(sampleReview, settings)
+	if text != "" {
+		t, err := ParseReviewTemplate(text)
+		if err == nil {
+			preview, err = executeReviewTemplate(t, newReviewTemplateData(sampleReview, app.Hashtags, settings))
+		}
+		if err != nil {
+			return nil, i18n.Errorf("Template error: %s", ctx.Localize(err))
+		}
+	}
+	c.Set("preview", preview)
+
+	return text, nil
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"encoding/json"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// ThreadMessage is a message sent into a topic of a forum group, the telegram library predates topics so it is sent by Send.
+type ThreadMessage struct {
+	tgbotapi.MessageConfig
+	MessageThreadId int
+}
+
+// InThread sends the message into the topic, messages to chats without topics are kept as is.
+func InThread(message tgbotapi.MessageConfig, messageThreadId int) tgbotapi.Chattable {
+	if messageThreadId == 0 {
+		return message
+	}
+
+	return ThreadMessage{MessageConfig: message, MessageThreadId: messageThreadId}
+}
+
+func (m ThreadMessage) params() (url.Values, error) {
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(m.ChatID, 10))
+	params.Set("message_thread_id", strconv.Itoa(m.MessageThreadId))
+	params.Set("text", m.Text)
+	params.Set("disable_web_page_preview", strconv.FormatBool(m.DisableWebPagePreview))
+	params.Set("disable_notification", strconv.FormatBool(m.DisableNotification))
+	if m.ParseMode != "" {
+		params.Set("parse_mode", m.ParseMode)
+	}
+	if m.ReplyToMessageID != 0 {
+		params.Set("reply_to_message_id", strconv.Itoa(m.ReplyToMessageID))
+	}
+	if m.ReplyMarkup != nil {
+		markup, err := json.Marshal(m.ReplyMarkup)
+		if err != nil {
+			return nil, err
+		}
+		params.Set("reply_markup", string(markup))
+	}
+
+	return params, nil
+}
+
+// Send sends whatever handlers put into the response channel, including messages into topics.
+// Messages into topics which were deleted go to General and apps stop using the topic.
+func Send(bot *tgbotapi.BotAPI, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	m, ok := c.(ThreadMessage)
+	if !ok {
+		return bot.Send(c)
+	}
+
+	message, err := sendThreadMessage(bot, m)
+	if te, ok := err.(tgbotapi.Error); ok && strings.Contains(te.Message, "message thread not found") {
+		log.Printf("[Send] chatId: %d, messageThreadId: %d: %s", m.ChatID, m.MessageThreadId, err)
+		datastore.Use(func(store *datastore.Datastore) {
+			forgetTopic(store, bot, m.ChatID, m.MessageThreadId)
+		})
+		return bot.Send(m.MessageConfig)
+	}
+
+	return message, err
+}
+
+func sendThreadMessage(bot *tgbotapi.BotAPI, m ThreadMessage) (tgbotapi.Message, error) {
+	params, err := m.params()
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	resp, err := bot.MakeRequest("sendMessage", params)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+
+	var message tgbotapi.Message
+	err = json.Unmarshal(resp.Result, &message)
+
+	return message, err
+}
+
+// forgetTopic makes apps posting to the deleted topic post to General, the chat is told how to get a topic back.
+func forgetTopic(store *datastore.Datastore, bot *tgbotapi.BotAPI, chatId int64, messageThreadId int) {
+	res, err := store.DB().Collection(collections.APPS).UpdateMany(store.Context, bson.M{
+		"chatid":          chatId,
+		"messagethreadid": messageThreadId,
+	}, bson.M{
+		"$unset": bson.M{
+			"messagethreadid": 1,
+		},
+	})
+	if err != nil || res.ModifiedCount == 0 {
+		utils.LogError(err)
+		return
+	}
+
+	language := LoadChatSettings(store, chatId).Language
+	_, err = bot.Send(tgbotapi.NewMessage(chatId, i18n.T(language,
+		"The topic for reviews was deleted, they will be posted to General. Turn topics on again in /settings")))
+	utils.LogError(err)
+}
+
+// isForum tells whether the chat is a supergroup with topics.
+func isForum(bot *tgbotapi.BotAPI, chatId int64) bool {
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(chatId, 10))
+	resp, err := bot.MakeRequest("getChat", params)
+	if err != nil {
+		utils.LogError(err)
+		return false
+	}
+
+	var chat struct {
+		IsForum bool `json:"is_forum"`
+	}
+	utils.LogError(json.Unmarshal(resp.Result, &chat))
+
+	return chat.IsForum
+}
+
+// createForumTopic creates a topic in the forum group and returns its message_thread_id.
+func createForumTopic(bot *tgbotapi.BotAPI, chatId int64, name string) (int, error) {
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(chatId, 10))
+	params.Set("name", name)
+	resp, err := bot.MakeRequest("createForumTopic", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var topic struct {
+		MessageThreadId int `json:"message_thread_id"`
+	}
+	err = json.Unmarshal(resp.Result, &topic)
+
+	return topic.MessageThreadId, err
+}
+
+func editForumTopic(bot *tgbotapi.BotAPI, chatId int64, messageThreadId int, name string) error {
+	params := url.Values{}
+	params.Set("chat_id", strconv.FormatInt(chatId, 10))
+	params.Set("message_thread_id", strconv.Itoa(messageThreadId))
+	params.Set("name", name)
+	_, err := bot.MakeRequest("editForumTopic", params)
+
+	return err
+}
+
+// createAppTopic creates a topic named after the app in its chat, reviews and alerts of the app are sent there.
+func (ctx Context) createAppTopic(appId primitive.ObjectID) error {
+	var app Application
+	err := ctx.Store.DB().Collection(collections.APPS).FindOne(ctx.Store.Context, bson.M{"_id": appId}).Decode(&app)
+	if err != nil {
+		return err
+	}
+
+	messageThreadId, err := createForumTopic(ctx.Bot, app.ChatId, app.GetName())
+	if err != nil {
+		return err
+	}
+	log.Printf("[createAppTopic] appId: %v, chatId: %d, messageThreadId: %d", app.ID, app.ChatId, messageThreadId)
+
+	ctx.updateApp(app.ID, bson.M{"messagethreadid": messageThreadId})
+	ctx.AppChanges <- 1
+
+	return nil
+}
+
+// createAppTopicIfForum gives the app its own topic when it is bound to a forum group.
+func (ctx Context) createAppTopicIfForum(appId primitive.ObjectID, chatId int64) {
+	if chatId > 0 || !isForum(ctx.Bot, chatId) {
+		return
+	}
+
+	if err := ctx.createAppTopic(appId); err != nil {
+		log.Printf("[createAppTopicIfForum] appId: %v, chatId: %d: %s", appId, chatId, err)
+		ctx.Resp <- tgbotapi.NewMessage(chatId, ctx.T(
+			"Couldn't create a topic for the app, reviews will be posted to General. Give me the right to manage topics and turn them on in /settings"))
+	}
+}
+
+// renameAppTopic keeps the name of the topic in sync with the name of the app.
+func (ctx Context) renameAppTopic(appId primitive.ObjectID) {
+	var app Application
+	err := ctx.Store.DB().Collection(collections.APPS).FindOne(ctx.Store.Context, bson.M{"_id": appId}).Decode(&app)
+	if err != nil || app.MessageThreadId == 0 {
+		return
+	}
+
+	utils.LogError(editForumTopic(ctx.Bot, app.ChatId, app.MessageThreadId, app.GetName()))
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/i18n"
+	"google-play-review-bot/utils"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// PauseUnreachableAfter is how long apps are checked for reviews which can't be posted, zero keeps checking them.
+var PauseUnreachableAfter = 24 * time.Hour
+
+// IsUnreachableError tells whether telegram refused the message because of the chat,
+// e.g. the bot was blocked, removed from the group, lost its rights in the channel or was muted in the group.
+func IsUnreachableError(err error) bool {
+	te, ok := err.(tgbotapi.Error)
+	if !ok {
+		return false
+	}
+
+	return strings.Contains(te.Message, "Forbidden") ||
+		strings.Contains(te.Message, "channel chat") ||
+		strings.Contains(te.Message, "chat not found") ||
+		strings.Contains(te.Message, "not enough rights to send")
+}
+
+// ChatIdOf returns the chat a response is sent to, or zero for responses which aren't sent to a chat.
+func ChatIdOf(c tgbotapi.Chattable) int64 {
+	switch m := c.(type) {
+	case TrackedMessage:
+		return ChatIdOf(m.Chattable)
+	case ThreadMessage:
+		return m.ChatID
+	case tgbotapi.MessageConfig:
+		return m.ChatID
+	case tgbotapi.DocumentConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return m.ChatID
+	}
+
+	return 0
+}
+
+// ChatUnreachable marks apps bound to the chat as unreachable instead of forgetting them, keys and settings are kept
+// so they can be bound again. Owners are told in private, once until a review is posted again.
+// It runs in its own goroutine next to the send loop, so errors are logged instead of panicking.
+func ChatUnreachable(store *datastore.Datastore, resp chan tgbotapi.Chattable, chatId int64, err error) {
+	c, e := store.DB().Collection(collections.APPS).Find(store.Context, bson.M{
+		"chatid":      chatId,
+		"unreachable": bson.M{"$exists": false},
+	})
+	if e != nil {
+		utils.LogError(e)
+		return
+	}
+
+	var apps []Application
+	if e := c.All(store.Context, &apps); e != nil {
+		utils.LogError(e)
+		return
+	}
+
+	for _, app := range apps {
+		info, e := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{
+			"_id":         app.ID,
+			"unreachable": bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{
+				"unreachable": Unreachable{Error: err.Error(), Since: time.Now()},
+			},
+		})
+		if e != nil || info.ModifiedCount == 0 {
+			utils.LogError(e)
+			continue
+		}
+		log.Printf("[ChatUnreachable] appId: %v, chatId: %d: %s", app.ID, chatId, err)
+
+		// the owner may have blocked the bot in the private chat the app is bound to
+		if int64(app.UserId) == chatId {
+			continue
+		}
+		language := LoadChatSettings(store, int64(app.UserId)).Language
+		text := i18n.T(language, "I can't post reviews of %s: %s\nGive me access to the chat again and use /rebind, or choose another one with /changegroup or /channel",
+			app.GetName(), err.Error())
+		if PauseUnreachableAfter > 0 {
+			text += "\n" + i18n.T(language, "Reviews will stop being checked in %s", PauseUnreachableAfter.String())
+		}
+		resp <- tgbotapi.NewMessage(int64(app.UserId), text)
+	}
+}
+
+// IsAppUnreachable reads whether the app is marked unreachable now, apps loaded by observers may be outdated.
+func IsAppUnreachable(store *datastore.Datastore, appId primitive.ObjectID) bool {
+	var app Application
+	err := store.DB().Collection(collections.APPS).FindOne(store.Context, bson.M{
+		"_id": appId,
+	}, options.FindOne().SetProjection(bson.M{"unreachable": 1})).Decode(&app)
+	if err != nil {
+		utils.LogError(err)
+		return false
+	}
+
+	return app.Unreachable != nil
+}
+
+// ClearUnreachable forgets the failure once a message is posted to the chat of the app again.
+func ClearUnreachable(store *datastore.Datastore, appId primitive.ObjectID) {
+	_, err := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{
+		"_id":         appId,
+		"unreachable": bson.M{"$exists": true},
+	}, bson.M{
+		"$unset": bson.M{
+			"unreachable": 1,
+		},
+	})
+	utils.LogError(err)
+}
+
+// PauseUnreachableApps stops checking for reviews of apps unreachable for longer than PauseUnreachableAfter
+// and tells whether any app was paused, so observers are rescheduled.
+func PauseUnreachableApps(store *datastore.Datastore, resp chan tgbotapi.Chattable) bool {
+	if PauseUnreachableAfter == 0 {
+		return false
+	}
+
+	c, err := store.DB().Collection(collections.APPS).Find(store.Context, bson.M{
+		"unreachable.since":  bson.M{"$lt": time.Now().Add(-PauseUnreachableAfter)},
+		"unreachable.paused": bson.M{"$ne": true},
+	})
+	utils.PanicOnError(err)
+
+	var apps []Application
+	utils.PanicOnError(c.All(store.Context, &apps))
+
+	paused := false
+	for _, app := range apps {
+		_, err := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{
+			"_id":         app.ID,
+			"unreachable": bson.M{"$exists": true},
+		}, bson.M{
+			"$set": bson.M{
+				"unreachable.paused": true,
+			},
+		})
+		if err != nil {
+			utils.LogError(err)
+			continue
+		}
+		log.Printf("[PauseUnreachableApps] appId: %v, chatId: %d", app.ID, app.ChatId)
+		paused = true
+
+		if int64(app.UserId) == app.ChatId {
+			continue
+		}
+		language := LoadChatSettings(store, int64(app.UserId)).Language
+		resp <- tgbotapi.NewMessage(int64(app.UserId), i18n.T(language,
+			"Stopped checking for reviews of %s, they still can't be posted. Use /rebind once I have access again", app.GetName()))
+	}
+
+	return paused
+}
+
+// RebindFlow tries to post to the chat of an unreachable app again, checking for reviews resumes when it works.
+func RebindFlow() *Flow {
+	return registerFlow(&Flow{
+		Id:    "Rebind",
+		Start: requireApps,
+		Steps: []Step{
+			chooseAppStep(),
+		},
+		Finish: func(ctx Context, c *Conversation) {
+			app, err := ctx.findUserApp(c.AppId())
+			utils.PanicOnError(err)
+
+			if app.ChatId == 0 {
+				ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("%s isn't bound to a chat, use /changegroup or /channel", app.GetName()))
+				return
+			}
+
+			ctx.Resp <- TrackedMessage{
+				Chattable: InThread(tgbotapi.NewMessage(app.ChatId, ctx.T("Reviews of %s will be posted here", app.GetName())), app.MessageThreadId),
+				OnSent: func(tgbotapi.Message) {
+					datastore.Use(func(store *datastore.Datastore) {
+						ClearUnreachable(store, app.ID)
+					})
+					ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T("%s is bound again", app.GetName()))
+					ctx.AppChanges <- 1
+				},
+				OnFailed: func(err error) {
+					ctx.Resp <- tgbotapi.NewMessage(ctx.ChatId(), ctx.T(
+						"Still can't post reviews of %s: %s\nChoose another chat with /changegroup or /channel", app.GetName(), err.Error()))
+				},
+			}
+		},
+	})
+}
@@ -0,0 +1,277 @@
+package i18n
+
+var de = catalog{
+	// adding apps
+	"Only admins of this group can add apps": "Nur Admins dieser Gruppe können Apps hinzufügen",
+	"Choose your os":                         "Wähle das Betriebssystem",
+	"Specify package name":                   "Gib den package name an",
+	"Specify app id":                         "Gib die app id an",
+	"Invalid OS":                             "Unbekanntes Betriebssystem",
+	"You already have app with packageName/appId = %s in this chat.\n Please provide another packageName or /reset": "In diesem Chat gibt es schon eine App mit packageName/appId = %s.\n Gib einen anderen packageName an oder /reset",
+	"Please send json key":       "Schicke bitte den json Schlüssel",
+	"Saved":                      "Gespeichert",
+	"Error downloading file: %s": "Datei konnte nicht geladen werden: %s",
+	"File saved":                 "Datei gespeichert",
+
+	// managing apps
+	"No apps to change":                                   "Keine Apps zum Ändern",
+	"You have no configured apps yet. /newapp ?":          "Du hast noch keine Apps. /newapp ?",
+	"You have next apps:":                                 "Deine Apps:",
+	"Please choose app":                                   "Wähle eine App",
+	"Language changed":                                    "Sprache geändert",
+	"Please provide %s":                                   "Bitte gib %s an",
+	"App name changed":                                    "App Name geändert",
+	"Great, check private chat for further instructions.": "Super, weitere Anweisungen findest du im privaten Chat.",
+	"Use next lint to add me to desired group: %s\n Or leave it here: %s": "Füge mich über diesen Link zur gewünschten Gruppe hinzu: %s\n Oder lass mich hier: %s",
+	"App store code changed":       "App Store Code geändert",
+	"I'm waiting for: %s\n":        "Ich warte auf: %s\n",
+	"State reset":                  "Zustand zurückgesetzt",
+	"Hello!":                       "Hallo!",
+	"Error occurred, try again...": "Ein Fehler ist aufgetreten, versuche es noch einmal...",
+	"Cancelled":                    "Abgebrochen",
+	"Nothing to cancel":            "Nichts abzubrechen",
+	"Stopped waiting for %s, send the command again to start over": "Ich warte nicht mehr auf %s, sende den Befehl noch einmal, um neu zu beginnen",
+	"Same as telegram":                                   "Wie in telegram",
+	"Choose the language of the bot":                     "Wähle die Sprache des Bots",
+	"The bot will talk to you in %s":                     "Der Bot spricht mit dir auf %s",
+	"Review not found":                                   "Bewertung nicht gefunden",
+	"Imported %d reviews into %s, %d were already known": "%d Bewertungen in %s importiert, %d waren schon bekannt",
+	"You have no android app with packageName = %s":      "Du hast keine android App mit packageName = %s",
+	"Error parsing report: %s":                           "Bericht konnte nicht gelesen werden: %s",
+	"missing column %q":                                  "Spalte %q fehlt",
+
+	// alerts
+	"Alert settings changed": "Alarmeinstellungen geändert",
+	"Acknowledged":           "Bestätigt",
+	"✅ Acknowledged by %s":   "✅ Bestätigt von %s",
+	"Expected: <min count> <ratio> <window hours> <cooldown hours>, e.g. \"%d %g %d %d\", or off": "Erwartet: <Mindestanzahl> <Faktor> <Zeitfenster in Stunden> <Pause in Stunden>, z.B. \"%d %g %d %d\", oder off",
+
+	// timezone
+	"server default, %s":                      "wie der Server, %s",
+	"Current timezone: %s\nPlease provide %s": "Aktuelle Zeitzone: %s\nBitte gib %s an",
+	"Share location":                          "Standort teilen",
+	"Timezone set to %s":                      "Zeitzone auf %s gesetzt",
+	"Choose date format":                      "Wähle das Datumsformat",
+	"Invalid date format":                     "Unbekanntes Datumsformat",
+	"Reviews will be dated like %s":           "Bewertungen werden so datiert: %s",
+	"Invalid UTC offset %s":                   "Ungültiger UTC Versatz %s",
+	"Unknown timezone %q":                     "Unbekannte Zeitzone %q",
+	"Unknown timezone %q, expected a name like Europe/Berlin or an offset like UTC+3": "Unbekannte Zeitzone %q, erwartet wird ein Name wie Europe/Berlin oder ein Versatz wie UTC+3",
+
+	// destinations
+	"Where should reviews be delivered?":    "Wohin sollen Bewertungen geschickt werden?",
+	"Unknown destination":                   "Unbekanntes Ziel",
+	"Destination %s added":                  "Ziel %s hinzugefügt",
+	"Signing secret of %s, save it now: %s": "Signaturgeheimnis für %s, speichere es jetzt: %s",
+	"I can't send you the signing secret, open @%s, send /start and add the webhook again": "Ich kann dir das Signaturgeheimnis nicht senden, öffne @%s, sende /start und füge den Webhook erneut hinzu",
+	"No destinations yet. /adddestination ?":                                               "Noch keine Ziele. /adddestination ?",
+	"Your destinations, tap to remove:":                                                    "Deine Ziele, tippe zum Entfernen:",
+	"Destination removed":                                                                  "Ziel entfernt",
+	"No apps yet":                                                                          "Noch keine Apps",
+	"No deliveries yet":                                                                    "Noch keine Zustellungen",
+	"Latest deliveries:":                                                                   "Letzte Zustellungen:",
+	"Please provide https url":                                                             "Bitte gib eine https url an",
+	"Expected url at %s":                                                                   "Erwartet wird eine url von %s",
+	"Email delivery is not configured on this bot":                                         "E-Mail Versand ist für diesen Bot nicht eingerichtet",
+	"Invalid email address: %s":                                                            "Ungültige E-Mail Adresse: %s",
+
+	// export
+	"No apps to export":        "Keine Apps zum Exportieren",
+	"Choose export format":     "Wähle das Exportformat",
+	"Invalid format":           "Unbekanntes Format",
+	"Preparing export...":      "Export wird vorbereitet...",
+	"Export failed: %s":        "Export fehlgeschlagen: %s",
+	"No reviews in this range": "Keine Bewertungen in diesem Zeitraum",
+
+	// hashtags and templates
+	"Hashtags turned off":                                      "Hashtags ausgeschaltet",
+	"Hashtags changed, e.g. %s":                                "Hashtags geändert, z.B. %s",
+	"Unknown hashtag %q, expected some of: %s, or all, or off": "Unbekannter Hashtag %q, erwartet wird etwas aus: %s, oder all, oder off",
+	"Send a template for review messages, or default to reset it. The default one is:": "Schicke eine Vorlage für Bewertungsnachrichten, oder default zum Zurücksetzen. Die Standardvorlage ist:",
+	"Template error: %s":                                  "Fehler in der Vorlage: %s",
+	"Template saved, above is a preview":                  "Vorlage gespeichert, oben ist eine Vorschau",
+	"Telegram rejected the template, it wasn't saved: %s": "Telegram hat die Vorlage abgelehnt, sie wurde nicht gespeichert: %s",
+	"template produced an empty message":                  "die Vorlage ergab eine leere Nachricht",
+	`Fields: {{.Header}} {{.App}} {{.Version}} {{.Build}} {{.Time}} {{.UserName}} {{.Device}} {{.DeviceName}} {{.Os}} {{.Rating}} {{.Stars}} {{.Text}} {{.OriginalText}} {{.Language}} {{.Url}} {{.OpenReview}} {{.Hashtags}}
+Functions: hashtag, e.g. #v{{hashtag .Version}}
+HTML tags supported by telegram may be used, field values are already escaped.`: `Felder: {{.Header}} {{.App}} {{.Version}} {{.Build}} {{.Time}} {{.UserName}} {{.Device}} {{.DeviceName}} {{.Os}} {{.Rating}} {{.Stars}} {{.Text}} {{.OriginalText}} {{.Language}} {{.Url}} {{.OpenReview}} {{.Hashtags}}
+Funktionen: hashtag, z.B. #v{{hashtag .Version}}
+HTML Tags, die telegram unterstützt, sind erlaubt, Feldwerte sind bereits maskiert.`,
+
+	// search
+	"Search expired, please repeat it":      "Suche abgelaufen, bitte wiederhole sie",
+	"Nothing found for: %s":                 "Nichts gefunden für: %s",
+	"Found %d reviews for: %s (page %d/%d)": "%d Bewertungen gefunden für: %s (Seite %d/%d)",
+	"◀️ Prev":                               "◀️ Zurück",
+	"Next ▶️":                               "Weiter ▶️",
+	"Unknown app: %s":                       "Unbekannte App: %s",
+	"Invalid rating: %s":                    "Ungültige Bewertung: %s",
+	"Invalid date: %s":                      "Ungültiges Datum: %s",
+	"Usage: /search <words> [app:<name>] [rating:<1-5 or 1-2>] [version:<name>] [from:yyyy-mm-dd] [to:yyyy-mm-dd] [device:<name>]": "Verwendung: /search <Wörter> [app:<Name>] [rating:<1-5 oder 1-2>] [version:<Name>] [from:yyyy-mm-dd] [to:yyyy-mm-dd] [device:<Name>]",
+
+	// what the bot is waiting for
+	"nothing":          "nichts",
+	"package name":     "den package name",
+	"json key":         "den json Schlüssel",
+	"choose app":       "die Wahl der App",
+	"language code":    "den Sprachcode",
+	"application name": "den App Namen",
+	"os selection":     "die Wahl des Betriebssystems",
+	"ios app id":       "die ios app id",
+	"AppStore code":    "den AppStore Ländercode",
+	"alert settings: <min count> <ratio> <window hours> <cooldown hours>, or off": "Alarmeinstellungen: <Mindestanzahl> <Faktor> <Zeitfenster in Stunden> <Pause in Stunden>, oder off",
+	"date range: <yyyy-mm-dd> [yyyy-mm-dd], or all":                               "Zeitraum: <yyyy-mm-dd> [yyyy-mm-dd], oder all",
+	"export format":    "das Exportformat",
+	"destination type": "die Art des Ziels",
+	"slack incoming webhook url, or bot token and channel: xoxb-... #channel": "slack incoming webhook url, oder Bot Token und Kanal: xoxb-... #channel",
+	"discord webhook url":        "discord webhook url",
+	"teams incoming webhook url": "teams incoming webhook url",
+	"webhook url":                "webhook url",
+	"email address, optionally followed by daily or weekly for a digest":      "E-Mail Adresse, optional gefolgt von daily oder weekly für eine Zusammenfassung",
+	"message template, or default":                                            "Nachrichtenvorlage, oder default",
+	"hashtags to add: rating version os manufacturer country, or all, or off": "Hashtags: rating version os manufacturer country, oder all, oder off",
+	"timezone like Europe/Berlin or UTC+3, or share location":                 "Zeitzone wie Europe/Berlin oder UTC+3, oder teile den Standort",
+	"date format": "Datumsformat",
+
+	// settings menu
+	"« Back":                   "« Zurück",
+	"« Apps":                   "« Apps",
+	"App not found":            "App nicht gefunden",
+	"Translation language: %s": "Übersetzungssprache: %s",
+	"AppStore country: %s":     "AppStore Land: %s",
+	"Chat: not bound yet":      "Chat: noch nicht verbunden",
+	"Chat: this one":           "Chat: dieser",
+	"Chat: another one":        "Chat: ein anderer",
+	"Alerts: off":              "Alarme: aus",
+	"Alerts: %d low ratings, %g times the usual rate in %d hours, then a pause of %d hours": "Alarme: %d schlechte Bewertungen, %g mal so oft wie üblich in %d Stunden, danach %d Stunden Pause",
+	"Template: default":           "Vorlage: Standard",
+	"Template: custom":            "Vorlage: eigene",
+	"Hashtags: off":               "Hashtags: aus",
+	"Hashtags: %s":                "Hashtags: %s",
+	"Destinations: %d":            "Ziele: %d",
+	"🔔 Turn alerts off":           "🔔 Alarme ausschalten",
+	"🔕 Turn alerts on":            "🔕 Alarme einschalten",
+	"✏️ Name":                     "✏️ Name",
+	"🌐 Translation":               "🌐 Übersetzung",
+	"🍏 AppStore":                  "🍏 AppStore",
+	"💬 Chat":                      "💬 Chat",
+	"⚙️ Alert thresholds":         "⚙️ Alarmschwellen",
+	"#️⃣ Hashtags":                "#️⃣ Hashtags",
+	"📝 Template":                  "📝 Vorlage",
+	"➕ Destination":               "➕ Ziel",
+	"📬 Deliveries":                "📬 Zustellungen",
+	"📤 Export":                    "📤 Export",
+	"Other…":                      "Andere…",
+	"Translate reviews of %s to:": "Bewertungen von %s übersetzen nach:",
+	"Tap hashtags to turn them on or off for %s": "Tippe auf Hashtags, um sie für %s ein- oder auszuschalten",
+
+	// command menu and /help
+	"Available commands:":                                          "Verfügbare Befehle:",
+	"Forget what the bot is waiting for":                           "Vergessen, worauf der Bot wartet",
+	"Cancel the current action":                                    "Aktuelle Aktion abbrechen",
+	"Show available commands":                                      "Verfügbare Befehle anzeigen",
+	"Add an app":                                                   "App hinzufügen",
+	"List your apps":                                               "Deine Apps auflisten",
+	"Change settings of your apps":                                 "Einstellungen deiner Apps ändern",
+	"Search reviews":                                               "Bewertungen durchsuchen",
+	"Change the language reviews are translated to":                "Sprache ändern, in die Bewertungen übersetzt werden",
+	"Rename an app":                                                "App umbenennen",
+	"Move an app to another chat":                                  "App in einen anderen Chat verschieben",
+	"Change the AppStore country of an iOS app":                    "AppStore Land einer iOS App ändern",
+	"Configure alerts about low ratings":                           "Alarme bei schlechten Bewertungen einrichten",
+	"Change the layout of review messages":                         "Aussehen der Bewertungsnachrichten ändern",
+	"Choose hashtags added to reviews":                             "Hashtags für Bewertungen wählen",
+	"Set the timezone and date format of this chat":                "Zeitzone und Datumsformat dieses Chats festlegen",
+	"Export reviews to a file":                                     "Bewertungen in eine Datei exportieren",
+	"Deliver reviews to Slack, Discord, Teams, a webhook or email": "Bewertungen an Slack, Discord, Teams, einen Webhook oder per E-Mail senden",
+	"List and remove destinations":                                 "Ziele auflisten und entfernen",
+	"Show latest deliveries to destinations":                       "Letzte Zustellungen an Ziele anzeigen",
+
+	// onboarding in /start
+	"I post reviews of your Google Play and App Store apps to telegram, translate them and alert you when ratings drop.\n\nWhich app do you want to add first?":                                                                                                                                                                "Ich poste Bewertungen deiner Google Play- und App Store-Apps in Telegram, übersetze sie und warne dich, wenn die Bewertungen sinken.\n\nWelche App möchtest du zuerst hinzufügen?",
+	"Step 1 of 3: create a service account\n\nOpen Google Cloud console, choose or create a project and create a service account in IAM & Admin → Service accounts. It doesn't need any roles.\n\nEnable Google Play Android Developer API in the same project.":                                                               "Schritt 1 von 3: Dienstkonto erstellen\n\nÖffne die Google Cloud Console, wähle oder erstelle ein Projekt und erstelle ein Dienstkonto unter IAM & Admin → Service accounts. Es braucht keine Rollen.\n\nAktiviere die Google Play Android Developer API im selben Projekt.",
+	"Step 2 of 3: grant access in Play Console\n\nOpen Users and permissions in Google Play Console and invite the email of the service account. Add your app to its app permissions and allow viewing app information and replying to reviews.":                                                                               "Schritt 2 von 3: Zugriff in der Play Console gewähren\n\nÖffne Nutzer und Berechtigungen in der Google Play Console und lade die E-Mail des Dienstkontos ein. Füge deine App zu seinen App-Berechtigungen hinzu und erlaube das Ansehen von App-Informationen und das Antworten auf Bewertungen.",
+	"Step 3 of 3: create a key\n\nOpen the service account, go to Keys → Add key → Create new key and choose JSON. Keep the downloaded file, I will ask you to send it.\n\nYou will also need the package name of the app, e.g. com.example.app, it's after id= in the Google Play link of the app.":                           "Schritt 3 von 3: Schlüssel erstellen\n\nÖffne das Dienstkonto, gehe zu Keys → Add key → Create new key und wähle JSON. Bewahre die heruntergeladene Datei auf, ich werde dich bitten, sie zu senden.\n\nDu brauchst außerdem den Paketnamen der App, z. B. com.example.app, er steht nach id= im Google Play-Link der App.",
+	"App Store reviews are public, so only the app ID is needed.\n\nIt's the number in the App Store link of the app, e.g. 123456789 in apps.apple.com/us/app/example/id123456789, or Apple ID in App Information in App Store Connect.\n\nReviews are read from the US store, you can change the country later in /settings.": "App Store-Bewertungen sind öffentlich, daher wird nur die App-ID benötigt.\n\nDas ist die Zahl im App Store-Link der App, z. B. 123456789 in apps.apple.com/us/app/example/id123456789, oder die Apple ID unter App-Informationen in App Store Connect.\n\nBewertungen werden aus dem US-Store gelesen, das Land kannst du später in /settings ändern.",
+	"Service accounts":         "Dienstkonten",
+	"Users and permissions":    "Nutzer und Berechtigungen",
+	"Next »":                   "Weiter »",
+	"➕ Add Android app":        "➕ Android-App hinzufügen",
+	"➕ Add iOS app":            "➕ iOS-App hinzufügen",
+	"⚙️ Settings":              "⚙️ Einstellungen",
+	"➕ Add app":                "➕ App hinzufügen",
+	"❓ Help":                   "❓ Hilfe",
+	"Welcome back! Your apps:": "Willkommen zurück! Deine Apps:",
+	"⚠️ JSON key is missing, add the app again with /newapp": "⚠️ JSON-Schlüssel fehlt, füge die App mit /newapp erneut hinzu",
+	"⚠️ Not bound to a chat, use /changegroup":               "⚠️ Mit keinem Chat verbunden, nutze /changegroup",
+	"✅ Reviews are posted here":                              "✅ Bewertungen werden hier gepostet",
+	"✅ Reviews are posted to another chat":                   "✅ Bewertungen werden in einen anderen Chat gepostet",
+	"Checked for reviews: %s":                                "Auf Bewertungen geprüft: %s",
+	"Latest review: %s":                                      "Letzte Bewertung: %s",
+
+	// apps managed from groups
+	"Only admins of this group can use /%s":                                                                  "Nur Admins dieser Gruppe können /%s verwenden",
+	"Only admins of this group can change settings":                                                          "Nur Admins dieser Gruppe können Einstellungen ändern",
+	"I'll ask for %s in our private chat, if there is no message from me open @%s and send /start":           "Ich frage im privaten Chat nach %s, falls keine Nachricht von mir kommt, öffne @%s und sende /start",
+	"I'm waiting for something else from you in our private chat, finish it or /cancel there and start over": "Im privaten Chat warte ich auf etwas anderes von dir, beende es oder sende dort /cancel und fang neu an",
+	"%s is added, reviews will be posted here":                                                               "%s wurde hinzugefügt, Bewertungen werden hier gepostet",
+
+	// forum topics
+	"Topic: its own":    "Thema: eigenes",
+	"🧵 Own topic":       "🧵 Eigenes Thema",
+	"🧵 Post to General": "🧵 In General posten",
+	"Couldn't create a topic: %s\nThe chat has to be a forum and I need the right to manage topics":                                            "Thema konnte nicht erstellt werden: %s\nDer Chat muss ein Forum sein und ich brauche das Recht, Themen zu verwalten",
+	"Couldn't create a topic for the app, reviews will be posted to General. Give me the right to manage topics and turn them on in /settings": "Für die App konnte kein Thema erstellt werden, Bewertungen werden in General gepostet. Gib mir das Recht, Themen zu verwalten, und schalte sie in /settings ein",
+
+	// channels
+	"channel": "Kanal",
+	"Forward any post from the channel here or send its @username. I have to be an admin of the channel with the right to post messages": "Leite einen beliebigen Beitrag aus dem Kanal hierher weiter oder sende seinen @username. Ich muss Admin des Kanals mit dem Recht zum Posten sein",
+	"Reviews will be posted to %s":                                                                "Bewertungen werden in %s gepostet",
+	"Forward a post from the channel or send its @username":                                       "Leite einen Beitrag aus dem Kanal weiter oder sende seinen @username",
+	"Can't find %s, check the username or forward a post from the channel":                        "%s nicht gefunden, prüfe den Benutzernamen oder leite einen Beitrag aus dem Kanal weiter",
+	"%s is not a channel":                                                                         "%s ist kein Kanal",
+	"I can't post to the channel, make me an admin with the right to post messages and try again": "Ich kann nicht in den Kanal posten, mach mich zum Admin mit dem Recht zum Posten und versuche es erneut",
+	"Only admins of the channel can post reviews to it":                                           "Nur Admins des Kanals können Bewertungen dorthin posten",
+	"📢 Channel":                           "📢 Kanal",
+	"Post reviews of an app to a channel": "Bewertungen einer App in einen Kanal posten",
+
+	// unreachable chats
+	"I can't post reviews of %s: %s\nGive me access to the chat again and use /rebind, or choose another one with /changegroup or /channel": "Ich kann Bewertungen von %s nicht posten: %s\nGib mir wieder Zugriff auf den Chat und nutze /rebind oder wähle mit /changegroup oder /channel einen anderen",
+	"Reviews will stop being checked in %s": "Bewertungen werden in %s nicht mehr abgerufen",
+	"Stopped checking for reviews of %s, they still can't be posted. Use /rebind once I have access again": "Bewertungen von %s werden nicht mehr abgerufen, sie können weiterhin nicht gepostet werden. Nutze /rebind, sobald ich wieder Zugriff habe",
+	"%s isn't bound to a chat, use /changegroup or /channel":                                               "%s ist mit keinem Chat verbunden, nutze /changegroup oder /channel",
+	"Reviews of %s will be posted here":                                                                    "Bewertungen von %s werden hier gepostet",
+	"%s is bound again":                                                                                    "%s ist wieder verbunden",
+	"Still can't post reviews of %s: %s\nChoose another chat with /changegroup or /channel":                "Bewertungen von %s können weiterhin nicht gepostet werden: %s\nWähle mit /changegroup oder /channel einen anderen Chat",
+	"⚠️ Can't post reviews: %s":                                                                            "⚠️ Bewertungen können nicht gepostet werden: %s",
+	"⏸ Checking for reviews is paused":                                                                     "⏸ Abrufen von Bewertungen ist pausiert",
+	"🔁 Rebind":                                                                                             "🔁 Neu verbinden",
+	"Fix it and use /rebind":                                                                               "Behebe es und nutze /rebind",
+	"Resume posting reviews after the bot got access again":                                                "Posten von Bewertungen fortsetzen, nachdem der Bot wieder Zugriff hat",
+	"Something went wrong, please start over":                                                              "Etwas ist schiefgelaufen, bitte fang von vorne an",
+	"The topic for reviews was deleted, they will be posted to General. Turn topics on again in /settings": "Das Thema für Bewertungen wurde gelöscht, sie werden in General gepostet. Schalte Themen in /settings wieder ein",
+
+	// review buttons and alerts
+	"Translate":     "Übersetzen",
+	"Show original": "Original anzeigen",
+	"Show full":     "Vollständig anzeigen",
+	"Open review":   "Bewertung öffnen",
+	"Acknowledge":   "Bestätigen",
+	"🚨🚨🚨 NEGATIVE REVIEW SPIKE 🚨🚨🚨":                                 "🚨🚨🚨 ANSTIEG NEGATIVER BEWERTUNGEN 🚨🚨🚨",
+	"%d of %d reviews rated %d★ or lower (%.0f%%, baseline %.0f%%)": "%d von %d Bewertungen mit %d★ oder weniger (%.0f%%, üblich %.0f%%)",
+	"Original":         "Original",
+	"Developer reply:": "Antwort des Entwicklers:",
+
+	// help
+	"Start the bot, in a group it binds the app from a /changegroup link":     "Den Bot starten, in einer Gruppe bindet es die App aus einem /changegroup-Link",
+	"Post reviews of the app from a /changegroup message to the private chat": "Bewertungen der App aus einer /changegroup-Nachricht im privaten Chat posten",
+
+	// import
+	"Import failed after %d new reviews: %s": "Import nach %d neuen Bewertungen fehlgeschlagen: %s",
+
+	// change group
+	"Usage: /private_<id>, use the link sent by /changegroup": "Verwendung: /private_<id>, nutze den Link aus /changegroup",
+
+	// alerts
+	"Already acknowledged": "Bereits bestätigt",
+}
@@ -0,0 +1,95 @@
+// Package i18n translates the bot's own messages, the english text of a message is its key in the catalogs.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+const Default = "en"
+
+type catalog map[string]string
+
+var catalogs = map[string]catalog{
+	"de": de,
+	"ru": ru,
+}
+
+// Languages lists supported languages in the order they are offered to users.
+var Languages = []string{"en", "de", "ru"}
+
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "Deutsch",
+	"ru": "Русский",
+}
+
+func Name(lang string) string {
+	return languageNames[lang]
+}
+
+// Normalize maps a telegram language_code like en-US to a supported language, falling back to Default.
+func Normalize(code string) string {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := languageNames[code]; ok {
+		return code
+	}
+
+	return Default
+}
+
+// T translates the message and formats it with args like fmt.Sprintf, args which are Messages are translated too.
+func T(lang string, key string, args ...interface{}) string {
+	text := key
+	if translated, ok := catalogs[lang][key]; ok {
+		text = translated
+	}
+	if len(args) == 0 {
+		return text
+	}
+
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if m, ok := arg.(Message); ok {
+			arg = m.Localize(lang)
+		}
+		localized[i] = arg
+	}
+
+	return fmt.Sprintf(text, localized...)
+}
+
+// Message is a translatable text which is rendered later, when the language of the reader is known.
+type Message struct {
+	Key  string
+	Args []interface{}
+}
+
+func Text(key string, args ...interface{}) Message {
+	return Message{Key: key, Args: args}
+}
+
+// Errorf works as fmt.Errorf, but the error can be shown translated with Localize.
+func Errorf(key string, args ...interface{}) error {
+	return Text(key, args...)
+}
+
+func (m Message) Localize(lang string) string {
+	return T(lang, m.Key, m.Args...)
+}
+
+func (m Message) Error() string {
+	return m.Localize(Default)
+}
+
+// Localize renders the error in the language, errors not created by this package are shown as is.
+func Localize(lang string, err error) string {
+	if m, ok := err.(Message); ok {
+		return m.Localize(lang)
+	}
+
+	return err.Error()
+}
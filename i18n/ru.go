@@ -0,0 +1,277 @@
+package i18n
+
+var ru = catalog{
+	// adding apps
+	"Only admins of this group can add apps": "Только администраторы группы могут добавлять приложения",
+	"Choose your os":                         "Выберите ОС",
+	"Specify package name":                   "Укажите package name",
+	"Specify app id":                         "Укажите app id",
+	"Invalid OS":                             "Неизвестная ОС",
+	"You already have app with packageName/appId = %s in this chat.\n Please provide another packageName or /reset": "В этом чате уже есть приложение с packageName/appId = %s.\n Укажите другой packageName или /reset",
+	"Please send json key":       "Пришлите json ключ",
+	"Saved":                      "Сохранено",
+	"Error downloading file: %s": "Не удалось скачать файл: %s",
+	"File saved":                 "Файл сохранён",
+
+	// managing apps
+	"No apps to change":                                   "Нет приложений для изменения",
+	"You have no configured apps yet. /newapp ?":          "У вас пока нет приложений. /newapp ?",
+	"You have next apps:":                                 "Ваши приложения:",
+	"Please choose app":                                   "Выберите приложение",
+	"Language changed":                                    "Язык изменён",
+	"Please provide %s":                                   "Укажите %s",
+	"App name changed":                                    "Название приложения изменено",
+	"Great, check private chat for further instructions.": "Отлично, дальнейшие инструкции в личном чате.",
+	"Use next lint to add me to desired group: %s\n Or leave it here: %s": "Добавьте меня в нужную группу по ссылке: %s\n Или оставьте здесь: %s",
+	"App store code changed":       "Код App Store изменён",
+	"I'm waiting for: %s\n":        "Я жду: %s\n",
+	"State reset":                  "Состояние сброшено",
+	"Hello!":                       "Привет!",
+	"Error occurred, try again...": "Произошла ошибка, попробуйте ещё раз...",
+	"Cancelled":                    "Отменено",
+	"Nothing to cancel":            "Нечего отменять",
+	"Stopped waiting for %s, send the command again to start over": "Я больше не жду %s, отправьте команду ещё раз, чтобы начать сначала",
+	"Same as telegram":                                   "Как в telegram",
+	"Choose the language of the bot":                     "Выберите язык бота",
+	"The bot will talk to you in %s":                     "Бот будет общаться с вами на языке: %s",
+	"Review not found":                                   "Отзыв не найден",
+	"Imported %d reviews into %s, %d were already known": "Импортировано отзывов: %d в %s, уже были известны: %d",
+	"You have no android app with packageName = %s":      "У вас нет android приложения с packageName = %s",
+	"Error parsing report: %s":                           "Не удалось разобрать отчёт: %s",
+	"missing column %q":                                  "нет колонки %q",
+
+	// alerts
+	"Alert settings changed": "Настройки оповещений изменены",
+	"Acknowledged":           "Принято",
+	"✅ Acknowledged by %s":   "✅ Принято: %s",
+	"Expected: <min count> <ratio> <window hours> <cooldown hours>, e.g. \"%d %g %d %d\", or off": "Ожидается: <минимум отзывов> <во сколько раз> <окно в часах> <пауза в часах>, например \"%d %g %d %d\", или off",
+
+	// timezone
+	"server default, %s":                      "как на сервере, %s",
+	"Current timezone: %s\nPlease provide %s": "Текущий часовой пояс: %s\nУкажите %s",
+	"Share location":                          "Отправить местоположение",
+	"Timezone set to %s":                      "Часовой пояс: %s",
+	"Choose date format":                      "Выберите формат даты",
+	"Invalid date format":                     "Неизвестный формат даты",
+	"Reviews will be dated like %s":           "Даты отзывов будут выглядеть так: %s",
+	"Invalid UTC offset %s":                   "Неверное смещение от UTC %s",
+	"Unknown timezone %q":                     "Неизвестный часовой пояс %q",
+	"Unknown timezone %q, expected a name like Europe/Berlin or an offset like UTC+3": "Неизвестный часовой пояс %q, ожидается название вроде Europe/Moscow или смещение вроде UTC+3",
+
+	// destinations
+	"Where should reviews be delivered?":    "Куда доставлять отзывы?",
+	"Unknown destination":                   "Неизвестное направление",
+	"Destination %s added":                  "Направление %s добавлено",
+	"Signing secret of %s, save it now: %s": "Секрет для подписи %s, сохраните его сейчас: %s",
+	"I can't send you the signing secret, open @%s, send /start and add the webhook again": "Не могу отправить вам секрет для подписи, откройте @%s, отправьте /start и добавьте вебхук ещё раз",
+	"No destinations yet. /adddestination ?":                                               "Направлений пока нет. /adddestination ?",
+	"Your destinations, tap to remove:":                                                    "Ваши направления, нажмите чтобы удалить:",
+	"Destination removed":                                                                  "Направление удалено",
+	"No apps yet":                                                                          "Приложений пока нет",
+	"No deliveries yet":                                                                    "Доставок пока не было",
+	"Latest deliveries:":                                                                   "Последние доставки:",
+	"Please provide https url":                                                             "Укажите https ссылку",
+	"Expected url at %s":                                                                   "Ожидается ссылка на %s",
+	"Email delivery is not configured on this bot":                                         "Отправка почты не настроена для этого бота",
+	"Invalid email address: %s":                                                            "Неверный адрес почты: %s",
+
+	// export
+	"No apps to export":        "Нет приложений для выгрузки",
+	"Choose export format":     "Выберите формат выгрузки",
+	"Invalid format":           "Неизвестный формат",
+	"Preparing export...":      "Готовлю выгрузку...",
+	"Export failed: %s":        "Выгрузка не удалась: %s",
+	"No reviews in this range": "В этом периоде нет отзывов",
+
+	// hashtags and templates
+	"Hashtags turned off":                                      "Хэштеги выключены",
+	"Hashtags changed, e.g. %s":                                "Хэштеги изменены, например %s",
+	"Unknown hashtag %q, expected some of: %s, or all, or off": "Неизвестный хэштег %q, ожидается что-то из: %s, или all, или off",
+	"Send a template for review messages, or default to reset it. The default one is:": "Пришлите шаблон сообщений с отзывами или default, чтобы вернуть стандартный. Стандартный шаблон:",
+	"Template error: %s":                                  "Ошибка в шаблоне: %s",
+	"Template saved, above is a preview":                  "Шаблон сохранён, выше пример",
+	"Telegram rejected the template, it wasn't saved: %s": "Telegram не принял шаблон, он не сохранён: %s",
+	"template produced an empty message":                  "шаблон дал пустое сообщение",
+	`Fields: {{.Header}} {{.App}} {{.Version}} {{.Build}} {{.Time}} {{.UserName}} {{.Device}} {{.DeviceName}} {{.Os}} {{.Rating}} {{.Stars}} {{.Text}} {{.OriginalText}} {{.Language}} {{.Url}} {{.OpenReview}} {{.Hashtags}}
+Functions: hashtag, e.g. #v{{hashtag .Version}}
+HTML tags supported by telegram may be used, field values are already escaped.`: `Поля: {{.Header}} {{.App}} {{.Version}} {{.Build}} {{.Time}} {{.UserName}} {{.Device}} {{.DeviceName}} {{.Os}} {{.Rating}} {{.Stars}} {{.Text}} {{.OriginalText}} {{.Language}} {{.Url}} {{.OpenReview}} {{.Hashtags}}
+Функции: hashtag, например #v{{hashtag .Version}}
+Можно использовать HTML теги, которые поддерживает telegram, значения полей уже экранированы.`,
+
+	// search
+	"Search expired, please repeat it":      "Поиск устарел, повторите его",
+	"Nothing found for: %s":                 "Ничего не найдено: %s",
+	"Found %d reviews for: %s (page %d/%d)": "Найдено отзывов: %d по запросу: %s (страница %d/%d)",
+	"◀️ Prev":                               "◀️ Назад",
+	"Next ▶️":                               "Вперёд ▶️",
+	"Unknown app: %s":                       "Неизвестное приложение: %s",
+	"Invalid rating: %s":                    "Неверная оценка: %s",
+	"Invalid date: %s":                      "Неверная дата: %s",
+	"Usage: /search <words> [app:<name>] [rating:<1-5 or 1-2>] [version:<name>] [from:yyyy-mm-dd] [to:yyyy-mm-dd] [device:<name>]": "Использование: /search <слова> [app:<название>] [rating:<1-5 или 1-2>] [version:<версия>] [from:yyyy-mm-dd] [to:yyyy-mm-dd] [device:<устройство>]",
+
+	// what the bot is waiting for
+	"nothing":          "ничего",
+	"package name":     "package name",
+	"json key":         "json ключ",
+	"choose app":       "выбор приложения",
+	"language code":    "код языка",
+	"application name": "название приложения",
+	"os selection":     "выбор ОС",
+	"ios app id":       "ios app id",
+	"AppStore code":    "код страны AppStore",
+	"alert settings: <min count> <ratio> <window hours> <cooldown hours>, or off": "настройки оповещений: <минимум отзывов> <во сколько раз> <окно в часах> <пауза в часах>, или off",
+	"date range: <yyyy-mm-dd> [yyyy-mm-dd], or all":                               "период: <yyyy-mm-dd> [yyyy-mm-dd], или all",
+	"export format":    "формат выгрузки",
+	"destination type": "тип направления",
+	"slack incoming webhook url, or bot token and channel: xoxb-... #channel": "ссылку на incoming webhook slack, или токен бота и канал: xoxb-... #channel",
+	"discord webhook url":        "ссылку на webhook discord",
+	"teams incoming webhook url": "ссылку на incoming webhook teams",
+	"webhook url":                "ссылку для webhook",
+	"email address, optionally followed by daily or weekly for a digest":      "адрес почты, можно добавить daily или weekly для дайджеста",
+	"message template, or default":                                            "шаблон сообщения, или default",
+	"hashtags to add: rating version os manufacturer country, or all, or off": "хэштеги: rating version os manufacturer country, или all, или off",
+	"timezone like Europe/Berlin or UTC+3, or share location":                 "часовой пояс как Europe/Berlin или UTC+3, или отправьте местоположение",
+	"date format": "формат даты",
+
+	// settings menu
+	"« Back":                   "« Назад",
+	"« Apps":                   "« Приложения",
+	"App not found":            "Приложение не найдено",
+	"Translation language: %s": "Язык перевода: %s",
+	"AppStore country: %s":     "Страна AppStore: %s",
+	"Chat: not bound yet":      "Чат: ещё не выбран",
+	"Chat: this one":           "Чат: этот",
+	"Chat: another one":        "Чат: другой",
+	"Alerts: off":              "Оповещения: выключены",
+	"Alerts: %d low ratings, %g times the usual rate in %d hours, then a pause of %d hours": "Оповещения: %d низких оценок, в %g раза чаще обычного за %d ч., затем пауза %d ч.",
+	"Template: default":           "Шаблон: стандартный",
+	"Template: custom":            "Шаблон: свой",
+	"Hashtags: off":               "Хэштеги: выключены",
+	"Hashtags: %s":                "Хэштеги: %s",
+	"Destinations: %d":            "Направлений: %d",
+	"🔔 Turn alerts off":           "🔔 Выключить оповещения",
+	"🔕 Turn alerts on":            "🔕 Включить оповещения",
+	"✏️ Name":                     "✏️ Название",
+	"🌐 Translation":               "🌐 Перевод",
+	"🍏 AppStore":                  "🍏 AppStore",
+	"💬 Chat":                      "💬 Чат",
+	"⚙️ Alert thresholds":         "⚙️ Пороги оповещений",
+	"#️⃣ Hashtags":                "#️⃣ Хэштеги",
+	"📝 Template":                  "📝 Шаблон",
+	"➕ Destination":               "➕ Направление",
+	"📬 Deliveries":                "📬 Доставки",
+	"📤 Export":                    "📤 Выгрузка",
+	"Other…":                      "Другой…",
+	"Translate reviews of %s to:": "Переводить отзывы %s на:",
+	"Tap hashtags to turn them on or off for %s": "Нажимайте на хэштеги, чтобы включить или выключить их для %s",
+
+	// command menu and /help
+	"Available commands:":                                          "Доступные команды:",
+	"Forget what the bot is waiting for":                           "Забыть, чего ждёт бот",
+	"Cancel the current action":                                    "Отменить текущее действие",
+	"Show available commands":                                      "Показать доступные команды",
+	"Add an app":                                                   "Добавить приложение",
+	"List your apps":                                               "Список ваших приложений",
+	"Change settings of your apps":                                 "Настройки ваших приложений",
+	"Search reviews":                                               "Поиск по отзывам",
+	"Change the language reviews are translated to":                "Изменить язык перевода отзывов",
+	"Rename an app":                                                "Переименовать приложение",
+	"Move an app to another chat":                                  "Перенести приложение в другой чат",
+	"Change the AppStore country of an iOS app":                    "Изменить страну AppStore для iOS приложения",
+	"Configure alerts about low ratings":                           "Настроить оповещения о низких оценках",
+	"Change the layout of review messages":                         "Изменить вид сообщений с отзывами",
+	"Choose hashtags added to reviews":                             "Выбрать хэштеги для отзывов",
+	"Set the timezone and date format of this chat":                "Часовой пояс и формат даты этого чата",
+	"Export reviews to a file":                                     "Выгрузить отзывы в файл",
+	"Deliver reviews to Slack, Discord, Teams, a webhook or email": "Доставлять отзывы в Slack, Discord, Teams, webhook или на почту",
+	"List and remove destinations":                                 "Список и удаление направлений",
+	"Show latest deliveries to destinations":                       "Последние доставки по направлениям",
+
+	// onboarding in /start
+	"I post reviews of your Google Play and App Store apps to telegram, translate them and alert you when ratings drop.\n\nWhich app do you want to add first?":                                                                                                                                                                "Я присылаю в telegram отзывы о ваших приложениях из Google Play и App Store, перевожу их и предупреждаю, когда оценки падают.\n\nКакое приложение добавим первым?",
+	"Step 1 of 3: create a service account\n\nOpen Google Cloud console, choose or create a project and create a service account in IAM & Admin → Service accounts. It doesn't need any roles.\n\nEnable Google Play Android Developer API in the same project.":                                                               "Шаг 1 из 3: создайте сервисный аккаунт\n\nОткройте консоль Google Cloud, выберите или создайте проект и создайте сервисный аккаунт в IAM & Admin → Service accounts. Роли ему не нужны.\n\nВключите Google Play Android Developer API в том же проекте.",
+	"Step 2 of 3: grant access in Play Console\n\nOpen Users and permissions in Google Play Console and invite the email of the service account. Add your app to its app permissions and allow viewing app information and replying to reviews.":                                                                               "Шаг 2 из 3: выдайте доступ в Play Console\n\nОткройте «Пользователи и разрешения» в Google Play Console и пригласите email сервисного аккаунта. Добавьте ему ваше приложение и разрешите просмотр информации о приложении и ответы на отзывы.",
+	"Step 3 of 3: create a key\n\nOpen the service account, go to Keys → Add key → Create new key and choose JSON. Keep the downloaded file, I will ask you to send it.\n\nYou will also need the package name of the app, e.g. com.example.app, it's after id= in the Google Play link of the app.":                           "Шаг 3 из 3: создайте ключ\n\nОткройте сервисный аккаунт, перейдите в Keys → Add key → Create new key и выберите JSON. Сохраните скачанный файл, я попрошу его прислать.\n\nЕщё понадобится имя пакета приложения, например com.example.app, оно стоит после id= в ссылке на приложение в Google Play.",
+	"App Store reviews are public, so only the app ID is needed.\n\nIt's the number in the App Store link of the app, e.g. 123456789 in apps.apple.com/us/app/example/id123456789, or Apple ID in App Information in App Store Connect.\n\nReviews are read from the US store, you can change the country later in /settings.": "Отзывы в App Store публичные, поэтому нужен только ID приложения.\n\nЭто число в ссылке на приложение в App Store, например 123456789 в apps.apple.com/us/app/example/id123456789, или Apple ID в разделе App Information в App Store Connect.\n\nОтзывы читаются из магазина США, страну можно изменить позже в /settings.",
+	"Service accounts":         "Сервисные аккаунты",
+	"Users and permissions":    "Пользователи и разрешения",
+	"Next »":                   "Далее »",
+	"➕ Add Android app":        "➕ Добавить Android приложение",
+	"➕ Add iOS app":            "➕ Добавить iOS приложение",
+	"⚙️ Settings":              "⚙️ Настройки",
+	"➕ Add app":                "➕ Добавить приложение",
+	"❓ Help":                   "❓ Помощь",
+	"Welcome back! Your apps:": "С возвращением! Ваши приложения:",
+	"⚠️ JSON key is missing, add the app again with /newapp": "⚠️ Нет JSON ключа, добавьте приложение заново через /newapp",
+	"⚠️ Not bound to a chat, use /changegroup":               "⚠️ Не привязано к чату, используйте /changegroup",
+	"✅ Reviews are posted here":                              "✅ Отзывы приходят сюда",
+	"✅ Reviews are posted to another chat":                   "✅ Отзывы приходят в другой чат",
+	"Checked for reviews: %s":                                "Проверено на отзывы: %s",
+	"Latest review: %s":                                      "Последний отзыв: %s",
+
+	// apps managed from groups
+	"Only admins of this group can use /%s":                                                                  "Только администраторы группы могут использовать /%s",
+	"Only admins of this group can change settings":                                                          "Только администраторы группы могут менять настройки",
+	"I'll ask for %s in our private chat, if there is no message from me open @%s and send /start":           "Я спрошу %s в личном чате, если от меня нет сообщения, откройте @%s и отправьте /start",
+	"I'm waiting for something else from you in our private chat, finish it or /cancel there and start over": "В личном чате я жду от вас другого, завершите это или отправьте там /cancel и начните заново",
+	"%s is added, reviews will be posted here":                                                               "%s добавлено, отзывы будут приходить сюда",
+
+	// forum topics
+	"Topic: its own":    "Тема: своя",
+	"🧵 Own topic":       "🧵 Своя тема",
+	"🧵 Post to General": "🧵 Писать в General",
+	"Couldn't create a topic: %s\nThe chat has to be a forum and I need the right to manage topics":                                            "Не получилось создать тему: %s\nЧат должен быть форумом, и мне нужно право управлять темами",
+	"Couldn't create a topic for the app, reviews will be posted to General. Give me the right to manage topics and turn them on in /settings": "Не получилось создать тему для приложения, отзывы будут приходить в General. Дайте мне право управлять темами и включите их в /settings",
+
+	// channels
+	"channel": "канал",
+	"Forward any post from the channel here or send its @username. I have to be an admin of the channel with the right to post messages": "Перешлите сюда любой пост из канала или отправьте его @username. Я должен быть администратором канала с правом публиковать сообщения",
+	"Reviews will be posted to %s":                                                                "Отзывы будут публиковаться в %s",
+	"Forward a post from the channel or send its @username":                                       "Перешлите пост из канала или отправьте его @username",
+	"Can't find %s, check the username or forward a post from the channel":                        "Не могу найти %s, проверьте username или перешлите пост из канала",
+	"%s is not a channel":                                                                         "%s — не канал",
+	"I can't post to the channel, make me an admin with the right to post messages and try again": "Я не могу публиковать в канал, сделайте меня администратором с правом публиковать сообщения и попробуйте снова",
+	"Only admins of the channel can post reviews to it":                                           "Публиковать отзывы в канал могут только его администраторы",
+	"📢 Channel":                           "📢 Канал",
+	"Post reviews of an app to a channel": "Публиковать отзывы приложения в канал",
+
+	// unreachable chats
+	"I can't post reviews of %s: %s\nGive me access to the chat again and use /rebind, or choose another one with /changegroup or /channel": "Не могу публиковать отзывы %s: %s\nВерните мне доступ к чату и используйте /rebind или выберите другой через /changegroup или /channel",
+	"Reviews will stop being checked in %s": "Проверка отзывов остановится через %s",
+	"Stopped checking for reviews of %s, they still can't be posted. Use /rebind once I have access again": "Проверка отзывов %s остановлена, их по-прежнему не получается публиковать. Используйте /rebind, когда у меня снова будет доступ",
+	"%s isn't bound to a chat, use /changegroup or /channel":                                               "%s не привязано к чату, используйте /changegroup или /channel",
+	"Reviews of %s will be posted here":                                                                    "Отзывы %s будут публиковаться здесь",
+	"%s is bound again":                                                                                    "%s снова привязано",
+	"Still can't post reviews of %s: %s\nChoose another chat with /changegroup or /channel":                "По-прежнему не могу публиковать отзывы %s: %s\nВыберите другой чат через /changegroup или /channel",
+	"⚠️ Can't post reviews: %s":                                                                            "⚠️ Не получается публиковать отзывы: %s",
+	"⏸ Checking for reviews is paused":                                                                     "⏸ Проверка отзывов приостановлена",
+	"🔁 Rebind":                                                                                             "🔁 Привязать снова",
+	"Fix it and use /rebind":                                                                               "Исправьте это и используйте /rebind",
+	"Resume posting reviews after the bot got access again":                                                "Возобновить публикацию отзывов, когда у бота снова есть доступ",
+	"Something went wrong, please start over":                                                              "Что-то пошло не так, пожалуйста, начните заново",
+	"The topic for reviews was deleted, they will be posted to General. Turn topics on again in /settings": "Тема для отзывов удалена, они будут публиковаться в General. Снова включите темы в /settings",
+
+	// review buttons and alerts
+	"Translate":     "Перевести",
+	"Show original": "Показать оригинал",
+	"Show full":     "Показать полностью",
+	"Open review":   "Открыть отзыв",
+	"Acknowledge":   "Принять",
+	"🚨🚨🚨 NEGATIVE REVIEW SPIKE 🚨🚨🚨":                                 "🚨🚨🚨 ВСПЛЕСК НЕГАТИВНЫХ ОТЗЫВОВ 🚨🚨🚨",
+	"%d of %d reviews rated %d★ or lower (%.0f%%, baseline %.0f%%)": "%d из %d отзывов с оценкой %d★ или ниже (%.0f%%, обычно %.0f%%)",
+	"Original":         "Оригинал",
+	"Developer reply:": "Ответ разработчика:",
+
+	// help
+	"Start the bot, in a group it binds the app from a /changegroup link":     "Запустить бота, в группе привязывает приложение по ссылке из /changegroup",
+	"Post reviews of the app from a /changegroup message to the private chat": "Публиковать отзывы приложения из сообщения /changegroup в личный чат",
+
+	// import
+	"Import failed after %d new reviews: %s": "Импорт прерван, добавлено новых отзывов: %d, ошибка: %s",
+
+	// change group
+	"Usage: /private_<id>, use the link sent by /changegroup": "Использование: /private_<id>, воспользуйтесь ссылкой из /changegroup",
+
+	// alerts
+	"Already acknowledged": "Уже принято",
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/scheduler"
+	"time"
+
+	"github.com/bugsnag/bugsnag-go"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// scheduleJanitor expires idle conversations, removes apps left behind by them and pauses unreachable apps.
+func scheduleJanitor(respChannel chan tgbotapi.Chattable, appChanges chan int) {
+	scheduler.NewScheduler().Schedule(func() {
+		defer bugsnag.AutoNotify()
+
+		datastore.Use(func(store *datastore.Datastore) {
+			handlers.ExpireConversations(store, respChannel)
+			handlers.RemovePartialApps(store)
+			if handlers.PauseUnreachableApps(store, respChannel) {
+				appChanges <- 1
+			}
+		})
+	}, time.Minute)
+}
@@ -4,13 +4,14 @@ import (
 	"google-play-review-bot/collections"
 	"google-play-review-bot/datastore"
 	"google-play-review-bot/handlers"
+	"google-play-review-bot/notifier"
 	"google-play-review-bot/utils"
 	"log"
 	"net/http"
 	"os"
 	"reflect"
 	"runtime/debug"
-	"strings"
+	"time"
 
 	"github.com/bugsnag/bugsnag-go"
 	"go.mongodb.org/mongo-driver/bson"
@@ -24,33 +25,62 @@ var Handlers []handlers.Handler
 
 func initHandlers(botUserName string) {
 	Handlers = []handlers.Handler{
+		handlers.ChannelPostConsumer{},
 		handlers.EditMessageConsumer{}, // we don't handle edit message events
-		handlers.Reset{},
+		handlers.InlineReviewLookup{},
+		handlers.Command{Handler: handlers.Reset{}, Command: "reset", Description: "Forget what the bot is waiting for", Scope: handlers.AllChats},
+		handlers.Command{Handler: handlers.Cancel{}, Command: "cancel", Description: "Cancel the current action", Scope: handlers.AllChats},
 		handlers.MigrateHandler{},
-		handlers.StartHandler{},
-
-		handlers.NewAppHandler{},
-		handlers.IosAndroidHandler{},
-		handlers.PackageNameReceiver{},
-		handlers.KeyReceiver{},
-		handlers.AppList{},
-
-		handlers.ChangeLanguage{},
-		handlers.ChangeLanguageReceiver{},
-		handlers.ChangeAppName{},
-		handlers.ChangeAppNameReceiver{},
-		handlers.ChangeGroup{},
-		handlers.ChangeGroupPrivateReceiver{},
-		handlers.ChooseAppReceiver{
-			BotUserName: botUserName,
-		},
-		handlers.ChangeAppStore{},
-		handlers.ChangeAppStoreReceiver{},
+		handlers.Command{Handler: handlers.StartHandler{}, Command: "start", Description: "Start the bot, in a group it binds the app from a /changegroup link", Scope: handlers.AllChats},
+		handlers.Command{Handler: handlers.Help{}, Command: "help", Description: "Show available commands", Scope: handlers.AllChats},
+		handlers.AcknowledgeAlert{},
+		handlers.SearchPage{},
+		handlers.ShowFullReview{},
+		handlers.ToggleTranslation{},
+		handlers.UserLanguageReceiver{},
+		handlers.SettingsMenu{},
+		handlers.Onboarding{},
+		handlers.ReviewReportReceiver{},
+		handlers.RemoveDestination{},
+
+		handlers.Command{Handler: handlers.NewAppFlow(), Command: "newapp", Description: "Add an app", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.AppList{}, Command: "apps", Description: "List your apps", Scope: handlers.AllChats},
+		handlers.Command{Handler: handlers.Settings{}, Command: "settings", Description: "Change settings of your apps", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.Search{}, Command: "search", Description: "Search reviews", Scope: handlers.AllChats},
+
+		handlers.Command{Handler: handlers.ChangeLanguageFlow(), Command: "changelanguage", Description: "Change the language reviews are translated to", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeAppNameFlow(), Command: "changeappname", Description: "Rename an app", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeGroupFlow(botUserName), Command: "changegroup", Description: "Move an app to another chat", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeGroupPrivateReceiver{}, Command: "private", Usage: "private_<id>", Description: "Post reviews of the app from a /changegroup message to the private chat", Scope: handlers.PrivateChats, Hidden: true},
+		handlers.Command{Handler: handlers.ChangeChannelFlow(), Command: "channel", Description: "Post reviews of an app to a channel", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.RebindFlow(), Command: "rebind", Description: "Resume posting reviews after the bot got access again", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeAppStoreFlow(), Command: "changeappstore", Description: "Change the AppStore country of an iOS app", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeAlertsFlow(), Command: "alerts", Description: "Configure alerts about low ratings", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeTemplateFlow(), Command: "template", Description: "Change the layout of review messages", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeHashtagsFlow(), Command: "hashtags", Description: "Choose hashtags added to reviews", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeTimezoneFlow(), Command: "timezone", Description: "Set the timezone and date format of this chat", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ChangeUserLanguage{}, Command: "language", Description: "Choose the language of the bot", Scope: handlers.AllChats},
+		handlers.Command{Handler: handlers.ExportFlow(), Command: "export", Description: "Export reviews to a file", Scope: handlers.AllChats},
+		handlers.Command{Handler: handlers.AddDestinationFlow(), Command: "adddestination", Description: "Deliver reviews to Slack, Discord, Teams, a webhook or email", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ListDestinations{}, Command: "destinations", Description: "List and remove destinations", Scope: handlers.AllChats, AdminOnly: true},
+		handlers.Command{Handler: handlers.ShowDeliveriesFlow(), Command: "deliveries", Description: "Show latest deliveries to destinations", Scope: handlers.AllChats},
 
 		//handlers.DefaultHandler{},
 	}
 }
 
+func serveHttp() {
+	tlsCert := os.Getenv("TLS_CERT")
+	tlsKey := os.Getenv("TLS_KEY")
+	if tlsCert != "" {
+		log.Printf("Using https")
+		go http.ListenAndServeTLS(":8443", tlsCert, tlsKey, nil)
+	} else {
+		log.Printf("Using http")
+		go http.ListenAndServe(":8443", nil)
+	}
+}
+
 func runBot(respChannel chan tgbotapi.Chattable, appChanges chan int) {
 	bot, err := tgbotapi.NewBotAPI(BotToken)
 	utils.PanicOnError(err)
@@ -63,18 +93,8 @@ func runBot(respChannel chan tgbotapi.Chattable, appChanges chan int) {
 	if useWebhook {
 		log.Printf("Using webhook")
 
-		tlsCert := os.Getenv("TLS_CERT")
-		tlsKey := os.Getenv("TLS_KEY")
-
 		bot.SetWebhook(tgbotapi.NewWebhook(webHookUrl + "/" + bot.Token))
 		updateChannel = bot.ListenForWebhook("/" + bot.Token)
-		if tlsCert != "" {
-			log.Printf("Using https")
-			go http.ListenAndServeTLS(":8443", tlsCert, tlsKey, nil)
-		} else {
-			log.Printf("Using http")
-			go http.ListenAndServe(":8443", nil)
-		}
 	} else {
 		log.Printf("Using getUpdate")
 		u := tgbotapi.NewUpdate(0)
@@ -83,12 +103,21 @@ func runBot(respChannel chan tgbotapi.Chattable, appChanges chan int) {
 		utils.PanicOnError(err)
 	}
 
+	// unsubscribe links of emails are served in both modes, with getUpdates only when PUBLIC_URL is set
+	if useWebhook || utils.PublicUrl() != "" {
+		http.HandleFunc(notifier.UnsubscribePath, notifier.UnsubscribeHandler)
+		serveHttp()
+	}
+
 	botInfo, err := bot.GetMe()
 	utils.PanicOnError(err)
 
 	log.Printf("Bot name: %s", botInfo.UserName)
 
 	initHandlers(botInfo.UserName)
+	handlers.RegisterCommands(bot, Handlers)
+	// flows are registered by initHandlers, the janitor needs them to roll back expired conversations
+	scheduleJanitor(respChannel, appChanges)
 
 	for {
 		select {
@@ -98,31 +127,30 @@ func runBot(respChannel chan tgbotapi.Chattable, appChanges chan int) {
 			}
 			go runHandlers(update, respChannel, bot, appChanges)
 		case resp := <-respChannel:
-			_, e := bot.Send(resp)
-			if te, ok := e.(tgbotapi.Error); ok && strings.Contains(te.Message, "Forbidden") {
-				dropChat(resp.(tgbotapi.MessageConfig).ChatID)
+			tracked, isTracked := resp.(handlers.TrackedMessage)
+			if isTracked {
+				resp = tracked.Chattable
+			}
+			message, e := handlers.Send(bot, resp)
+			if chatId := handlers.ChatIdOf(resp); chatId != 0 && handlers.IsUnreachableError(e) {
+				// apps of the chat are kept, the bot may be added back or the app bound to another chat
+				go datastore.Use(func(store *datastore.Datastore) {
+					handlers.ChatUnreachable(store, respChannel, chatId, e)
+				})
 			} else {
 				utils.LogError(e)
 			}
+			if isTracked {
+				if e == nil {
+					go tracked.OnSent(message)
+				} else if tracked.OnFailed != nil {
+					go tracked.OnFailed(e)
+				}
+			}
 		}
 	}
 }
 
-func dropChat(id int64) {
-	store, cancel := datastore.Get()
-	defer cancel()
-
-	_, err := store.DB().Collection(collections.CHAT).DeleteOne(store.Context, bson.M{
-		"chatid": id,
-	})
-	utils.LogError(err)
-
-	_, err = store.DB().Collection(collections.APPS).DeleteOne(store.Context, bson.M{
-		"chatid": id,
-	})
-	utils.LogError(err)
-}
-
 func logMessage(update tgbotapi.Update) {
 	store, cancel := datastore.Get()
 	defer cancel()
@@ -136,7 +164,7 @@ func runHandlers(update tgbotapi.Update, respChannel chan tgbotapi.Chattable, bo
 	defer cancel()
 
 	//noinspection GoStructInitializationWithoutFieldNames
-	c := handlers.Context{update, respChannel, appChanges, store, bot}
+	c := handlers.Context{update, respChannel, appChanges, store, bot, handlers.DetectLanguage(store, update)}
 	defer func() {
 		if r := recover(); r != nil {
 			bugsnag.Notify(utils.MakeError(r))
@@ -144,7 +172,7 @@ func runHandlers(update tgbotapi.Update, respChannel chan tgbotapi.Chattable, bo
 			log.Printf("Panic in handler: %s %s\n%s", reflect.TypeOf(r), r, debug.Stack())
 			chatId := c.SafeChatId()
 			if chatId != 0 {
-				respChannel <- tgbotapi.NewMessage(c.ChatId(), "Error occurred, try again...")
+				respChannel <- tgbotapi.NewMessage(c.ChatId(), c.T("Error occurred, try again..."))
 			}
 		}
 	}()
@@ -174,6 +202,9 @@ func observe(
 			"chatid": bson.M{
 				"$exists": true,
 			},
+			"unreachable.paused": bson.M{
+				"$ne": true,
+			},
 		}
 		if os == "android" {
 			findQuery["keyfile"] = bson.M{
@@ -222,6 +253,19 @@ func main() {
 		ReleaseStage:    bugsnagStage,
 	})
 
+	datastore.Connect()
+
+	if timeout, ok := os.LookupEnv("CONVERSATION_TIMEOUT"); ok {
+		duration, err := time.ParseDuration(timeout)
+		utils.PanicOnError(err)
+		handlers.ConversationTimeout = duration
+	}
+	if pause, ok := os.LookupEnv("PAUSE_UNREACHABLE_AFTER"); ok {
+		duration, err := time.ParseDuration(pause)
+		utils.PanicOnError(err)
+		handlers.PauseUnreachableAfter = duration
+	}
+
 	respChannel := make(chan tgbotapi.Chattable, 5)
 	appChanges := make(chan int, 5)
 
@@ -243,5 +287,8 @@ func main() {
 
 	appChanges <- 0
 
+	datastore.Use(handlers.ResetMovedChatStates)
+	scheduleDigests()
+
 	runBot(respChannel, appChanges)
 }
@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/utils"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	maxAttempts     = 5
+	retryBackoff    = 30 * time.Second
+	laneIdleTimeout = 10 * time.Minute
+	laneSize        = 100
+)
+
+// PermanentError marks failures which will not go away by retrying, e.g. a deleted webhook.
+type PermanentError struct {
+	error
+}
+
+type delivery struct {
+	notifier Notifier
+	app      handlers.Application
+	review   handlers.UserReview
+	attempt  int
+}
+
+// lane delivers to one destination, pending counts deliveries handed to it and not received yet,
+// the lane isn't closed while there are some.
+type lane struct {
+	deliveries chan delivery
+	pending    int
+}
+
+var (
+	lanes     = map[string]*lane{}
+	lanesLock sync.Mutex
+)
+
+// enqueue hands the delivery to the lane of its notifier, so every destination gets reviews in order
+// while a slow destination doesn't hold back the others. Deliveries to a destination which fell too far behind are dropped.
+func enqueue(d delivery) {
+	key := d.notifier.Key()
+
+	lanesLock.Lock()
+	l, ok := lanes[key]
+	if !ok {
+		l = &lane{deliveries: make(chan delivery, laneSize)}
+		lanes[key] = l
+		go deliver(key, l)
+	}
+	l.pending++
+	lanesLock.Unlock()
+
+	select {
+	case l.deliveries <- d:
+	default:
+		lanesLock.Lock()
+		l.pending--
+		lanesLock.Unlock()
+
+		err := fmt.Errorf("%d deliveries to %s are waiting already, dropped review %s", laneSize, key, d.review.ReviewId)
+		logDelivery(d, err)
+		utils.LogError(err)
+	}
+}
+
+// deliver sends deliveries of the lane one by one and closes the lane once it is idle for laneIdleTimeout.
+func deliver(key string, l *lane) {
+	for {
+		select {
+		case d := <-l.deliveries:
+			lanesLock.Lock()
+			l.pending--
+			lanesLock.Unlock()
+
+			deliverWithRetries(d)
+		case <-time.After(laneIdleTimeout):
+			lanesLock.Lock()
+			if l.pending == 0 {
+				delete(lanes, key)
+				lanesLock.Unlock()
+				return
+			}
+			lanesLock.Unlock()
+		}
+	}
+}
+
+// deliverWithRetries retries in place, deliveries queued behind a failing one wait, so the order is kept.
+func deliverWithRetries(d delivery) {
+	for {
+		d.attempt++
+		err := d.notifier.Notify(d.app, d.review)
+		logDelivery(d, err)
+		if err == nil {
+			return
+		}
+
+		_, permanent := err.(PermanentError)
+		log.Printf("[%s] Delivery of %s failed, attempt %d: %s", d.app.PackageName, d.notifier.Key(), d.attempt, err)
+		if permanent || d.attempt >= maxAttempts {
+			utils.LogError(err)
+			return
+		}
+
+		time.Sleep(retryBackoff * time.Duration(1<<uint(d.attempt-1)))
+	}
+}
+
+func logDelivery(d delivery, err error) {
+	entry := handlers.DeliveryLog{
+		AppId:       d.app.ID,
+		Destination: d.notifier.Key(),
+		ReviewId:    d.review.ReviewId,
+		Attempt:     d.attempt,
+		Created:     time.Now(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+
+	datastore.Use(func(store *datastore.Datastore) {
+		_, err := store.DB().Collection(collections.DELIVERIES).InsertOne(store.Context, entry)
+		utils.LogError(err)
+	})
+}
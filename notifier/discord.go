@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"google-play-review-bot/handlers"
+	"strings"
+	"time"
+)
+
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+var discordRatingColors = map[int]int{
+	1: 0xE74C3C,
+	2: 0xE67E22,
+	3: 0xF1C40F,
+	4: 0x2ECC71,
+	5: 0x27AE60,
+}
+
+// Discord posts reviews as embeds to a channel webhook.
+type Discord struct {
+	destination handlers.Destination
+}
+
+var _ Notifier = Discord{}
+
+func newDiscord(destination handlers.Destination) Notifier {
+	return Discord{destination: destination}
+}
+
+type discordEmbedAuthor struct {
+	Name string `json:"name"`
+}
+
+type discordEmbedFooter struct {
+	Text string `json:"text"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Author      *discordEmbedAuthor `json:"author,omitempty"`
+	Footer      *discordEmbedFooter `json:"footer,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+func (d Discord) Notify(app handlers.Application, review handlers.UserReview) error {
+	embed := discordEmbed{
+		Title:       limitText(review.Header(), discordTitleLimit),
+		Description: limitText(review.RatingIcons()+"\n"+strings.TrimSpace(review.Text), discordDescriptionLimit),
+		Color:       discordRatingColors[review.Rating],
+	}
+	if review.UserName != "" {
+		embed.Author = &discordEmbedAuthor{Name: review.UserName}
+	}
+	if device := review.DeviceDescription(); device != "" {
+		embed.Footer = &discordEmbedFooter{Text: device}
+	}
+	if !review.Time.IsZero() {
+		embed.Timestamp = review.Time.UTC().Format(time.RFC3339)
+	}
+
+	_, err := postJSON(d.destination.URL, nil, discordMessage{Embeds: []discordEmbed{embed}})
+	return err
+}
+
+func (d Discord) Key() string {
+	return "discord:" + d.destination.ID.Hex()
+}
+
+func limitText(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit-1]) + "…"
+	}
+
+	return text
+}
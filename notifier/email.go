@@ -0,0 +1,235 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/utils"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTP settings, SMTP_TLS set to "tls" means implicit TLS, otherwise STARTTLS is used when the server offers it.
+var (
+	smtpHost     = os.Getenv("SMTP_HOST")
+	smtpPort     = os.Getenv("SMTP_PORT")
+	smtpUsername = os.Getenv("SMTP_USERNAME")
+	smtpPassword = os.Getenv("SMTP_PASSWORD")
+	smtpFrom     = os.Getenv("SMTP_FROM")
+	smtpTls      = os.Getenv("SMTP_TLS")
+
+	// unsubscribe links are served by the http server of the bot
+	publicUrl = utils.PublicUrl()
+)
+
+const UnsubscribePath = "/unsubscribe"
+
+// DigestNotifier is implemented by notifiers able to deliver many reviews at once.
+type DigestNotifier interface {
+	NotifyDigest(app handlers.Application, reviews []handlers.UserReview) error
+}
+
+// Email sends reviews, or digests of them, as multipart HTML and plain text messages.
+type Email struct {
+	destination handlers.Destination
+}
+
+var _ Notifier = Email{}
+var _ DigestNotifier = Email{}
+
+func newEmail(destination handlers.Destination) Notifier {
+	return Email{destination: destination}
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+{{range .Reviews}}<div style="margin-bottom: 24px">
+<div><b>{{.HeaderText}}</b></div>
+{{if .UserName}}<div><i>{{.UserName}}</i></div>{{end}}
+{{with .DeviceDescription}}<div style="color: #666">{{.}}</div>{{end}}
+<div>{{.RatingIcons}}</div>
+<div style="white-space: pre-wrap">{{.Text}}</div>
+</div>
+{{end}}{{if .Unsubscribe}}<hr><div style="color: #999; font-size: small"><a href="{{.Unsubscribe}}">Unsubscribe</a> from reviews of {{.App}}</div>{{end}}
+</body></html>
+`))
+
+func (e Email) Notify(app handlers.Application, review handlers.UserReview) error {
+	subject := fmt.Sprintf("[%s] %s review", app.GetName(), strings.Repeat("★", review.Rating))
+	if review.UserName != "" {
+		subject += " from " + review.UserName
+	}
+	return e.send(app, subject, []handlers.UserReview{review})
+}
+
+func (e Email) NotifyDigest(app handlers.Application, reviews []handlers.UserReview) error {
+	subject := fmt.Sprintf("[%s] %d new reviews", app.GetName(), len(reviews))
+	return e.send(app, subject, reviews)
+}
+
+func (e Email) Key() string {
+	return "email:" + e.destination.ID.Hex()
+}
+
+func (e Email) unsubscribeUrl() string {
+	if publicUrl == "" {
+		return ""
+	}
+
+	return publicUrl + UnsubscribePath + "?" + url.Values{
+		"d": {e.destination.ID.Hex()},
+		"t": {e.destination.Secret},
+	}.Encode()
+}
+
+// emailReview carries the header formatted for the chat of the app, so emails are dated the same way as messages.
+type emailReview struct {
+	handlers.UserReview
+	HeaderText string
+}
+
+func (e Email) send(app handlers.Application, subject string, reviews []handlers.UserReview) error {
+	unsubscribe := e.unsubscribeUrl()
+
+	var settings handlers.ChatSettings
+	datastore.Use(func(store *datastore.Datastore) {
+		settings = handlers.LoadChatSettings(store, app.ChatId)
+	})
+
+	var plain bytes.Buffer
+	var formatted []emailReview
+	for i, r := range reviews {
+		if i > 0 {
+			plain.WriteString("\n\n")
+		}
+		plain.WriteString(r.FormatIn(settings))
+		formatted = append(formatted, emailReview{r, r.HeaderIn(settings)})
+	}
+	if unsubscribe != "" {
+		plain.WriteString("\n\n--\nUnsubscribe: ")
+		plain.WriteString(unsubscribe)
+	}
+
+	var html bytes.Buffer
+	err := emailTemplate.Execute(&html, struct {
+		App         string
+		Reviews     []emailReview
+		Unsubscribe string
+	}{app.GetName(), formatted, unsubscribe})
+	if err != nil {
+		return PermanentError{err}
+	}
+
+	var message bytes.Buffer
+	body := multipart.NewWriter(&message)
+	headers := []string{
+		"From: " + smtpFrom,
+		"To: " + e.destination.Address,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+	if unsubscribe != "" {
+		headers = append(headers, "List-Unsubscribe: <"+unsubscribe+">", "List-Unsubscribe-Post: List-Unsubscribe=One-Click")
+	}
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=UTF-8", plain.Bytes()},
+		{"text/html; charset=UTF-8", html.Bytes()},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err = qp.Write(part.content); err != nil {
+			return err
+		}
+		if err = qp.Close(); err != nil {
+			return err
+		}
+	}
+	if err = body.Close(); err != nil {
+		return err
+	}
+
+	return sendMail(e.destination.Address, message.Bytes())
+}
+
+func sendMail(to string, message []byte) error {
+	if smtpHost == "" {
+		return PermanentError{fmt.Errorf("SMTP_HOST is not configured")}
+	}
+
+	port := smtpPort
+	if port == "" {
+		port = "587"
+		if smtpTls == "tls" {
+			port = "465"
+		}
+	}
+	address := net.JoinHostPort(smtpHost, port)
+
+	var auth smtp.Auth
+	if smtpUsername != "" {
+		auth = smtp.PlainAuth("", smtpUsername, smtpPassword, smtpHost)
+	}
+
+	if smtpTls != "tls" {
+		// smtp.SendMail upgrades with STARTTLS whenever the server offers it
+		return smtp.SendMail(address, auth, smtpFrom, []string{to}, message)
+	}
+
+	conn, err := tls.Dial("tcp", address, &tls.Config{ServerName: smtpHost})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, smtpHost)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err = client.Mail(smtpFrom); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(message); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"fmt"
+	"google-play-review-bot/handlers"
+	"log"
+
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+// Notifier delivers a review of an app to a single place.
+type Notifier interface {
+	Notify(app handlers.Application, review handlers.UserReview) error
+	// Key identifies the place, deliveries to the same place are made one by one.
+	Key() string
+}
+
+var destinationNotifiers = map[string]func(destination handlers.Destination) Notifier{
+	"slack":   newSlack,
+	"discord": newDiscord,
+	"teams":   newTeams,
+	"webhook": newWebhook,
+	"email":   newEmail,
+}
+
+// ForApp returns a notifier for the telegram chat of the app followed by its other destinations.
+func ForApp(app handlers.Application, respChannel chan tgbotapi.Chattable) []Notifier {
+	var notifiers []Notifier
+	if app.ChatId != 0 {
+		notifiers = append(notifiers, Telegram{ChatId: app.ChatId, MessageThreadId: app.MessageThreadId, Resp: respChannel})
+	}
+
+	for _, destination := range app.Destinations {
+		if destination.Digest != "" {
+			continue
+		}
+		newNotifier, ok := destinationNotifiers[destination.Type]
+		if !ok {
+			log.Printf("[%s] Unknown destination type %s", app.PackageName, destination.Type)
+			continue
+		}
+		notifiers = append(notifiers, newNotifier(destination))
+	}
+
+	return notifiers
+}
+
+// Notify fans the review out to every notifier of the app, failed deliveries are retried in background.
+func Notify(app handlers.Application, review handlers.UserReview, respChannel chan tgbotapi.Chattable) {
+	for _, n := range ForApp(app, respChannel) {
+		enqueue(delivery{
+			notifier: n,
+			app:      app,
+			review:   review,
+		})
+	}
+}
+
+// NotifyDigest sends reviews collected since the last digest to a destination which receives digests.
+func NotifyDigest(app handlers.Application, destination handlers.Destination, reviews []handlers.UserReview) error {
+	newNotifier, ok := destinationNotifiers[destination.Type]
+	if !ok {
+		return fmt.Errorf("unknown destination type %s", destination.Type)
+	}
+
+	n, ok := newNotifier(destination).(DigestNotifier)
+	if !ok {
+		return fmt.Errorf("destination type %s doesn't support digests", destination.Type)
+	}
+
+	return n.NotifyDigest(app, reviews)
+}
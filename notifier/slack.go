@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"google-play-review-bot/handlers"
+	"strings"
+)
+
+const (
+	slackPostMessageUrl = "https://slack.com/api/chat.postMessage"
+	slackTextLimit      = 3000
+)
+
+// Slack posts reviews either to an incoming webhook or, with a bot token, to a channel.
+type Slack struct {
+	destination handlers.Destination
+}
+
+var _ Notifier = Slack{}
+
+func newSlack(destination handlers.Destination) Notifier {
+	return Slack{destination: destination}
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackMessage struct {
+	Channel string       `json:"channel,omitempty"`
+	Text    string       `json:"text"`
+	Blocks  []slackBlock `json:"blocks"`
+}
+
+func (s Slack) Notify(app handlers.Application, review handlers.UserReview) error {
+	message := slackMessage{
+		Text:   review.Format(),
+		Blocks: slackBlocks(review),
+	}
+
+	if s.destination.Token == "" {
+		_, err := postJSON(s.destination.URL, nil, message)
+		return err
+	}
+
+	message.Channel = s.destination.Channel
+	body, err := postJSON(slackPostMessageUrl, map[string]string{
+		"Authorization": "Bearer " + s.destination.Token,
+	}, message)
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return fmt.Errorf("slack %s: %s", s.destination.Channel, resp.Error)
+	}
+
+	return nil
+}
+
+func (s Slack) Key() string {
+	return "slack:" + s.destination.ID.Hex()
+}
+
+func slackBlocks(review handlers.UserReview) []slackBlock {
+	blocks := []slackBlock{{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: "*" + slackEscape(review.Header()) + "*"},
+	}}
+
+	var context []slackText
+	if review.UserName != "" {
+		context = append(context, slackText{Type: "mrkdwn", Text: "_" + slackEscape(review.UserName) + "_"})
+	}
+	if device := review.DeviceDescription(); device != "" {
+		context = append(context, slackText{Type: "mrkdwn", Text: slackEscape(device)})
+	}
+	if len(context) > 0 {
+		blocks = append(blocks, slackBlock{Type: "context", Elements: context})
+	}
+
+	text := review.RatingIcons()
+	if body := strings.TrimSpace(review.Text); body != "" {
+		text += "\n" + slackEscape(body)
+	}
+	text = limitText(text, slackTextLimit)
+	blocks = append(blocks, slackBlock{
+		Type: "section",
+		Text: &slackText{Type: "mrkdwn", Text: text},
+	})
+
+	return blocks
+}
+
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"google-play-review-bot/handlers"
+	"strings"
+)
+
+// Teams posts reviews as Adaptive Cards to an incoming webhook.
+type Teams struct {
+	destination handlers.Destination
+}
+
+var _ Notifier = Teams{}
+
+func newTeams(destination handlers.Destination) Notifier {
+	return Teams{destination: destination}
+}
+
+type teamsTextBlock struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Wrap     bool   `json:"wrap"`
+	Weight   string `json:"weight,omitempty"`
+	Size     string `json:"size,omitempty"`
+	IsSubtle bool   `json:"isSubtle,omitempty"`
+	Spacing  string `json:"spacing,omitempty"`
+}
+
+type teamsCard struct {
+	Schema  string           `json:"$schema"`
+	Type    string           `json:"type"`
+	Version string           `json:"version"`
+	Body    []teamsTextBlock `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+func (t Teams) Notify(app handlers.Application, review handlers.UserReview) error {
+	body := []teamsTextBlock{{
+		Type:   "TextBlock",
+		Text:   review.Header(),
+		Wrap:   true,
+		Weight: "Bolder",
+		Size:   "Medium",
+	}}
+
+	var subtitle []string
+	if review.UserName != "" {
+		subtitle = append(subtitle, review.UserName)
+	}
+	if device := review.DeviceDescription(); device != "" {
+		subtitle = append(subtitle, device)
+	}
+	if len(subtitle) > 0 {
+		body = append(body, teamsTextBlock{
+			Type:     "TextBlock",
+			Text:     strings.Join(subtitle, " · "),
+			Wrap:     true,
+			IsSubtle: true,
+			Spacing:  "None",
+		})
+	}
+
+	body = append(body, teamsTextBlock{
+		Type: "TextBlock",
+		Text: review.RatingIcons() + "\n\n" + strings.TrimSpace(review.Text),
+		Wrap: true,
+	})
+
+	_, err := postJSON(t.destination.URL, nil, teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: teamsCard{
+				Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	})
+	return err
+}
+
+func (t Teams) Key() string {
+	return "teams:" + t.destination.ID.Hex()
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"fmt"
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/utils"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	tgbotapi "gopkg.in/telegram-bot-api.v4"
+)
+
+type Telegram struct {
+	ChatId int64
+	// MessageThreadId is the topic of a forum group, see handlers.InThread
+	MessageThreadId int
+	Resp            chan tgbotapi.Chattable
+}
+
+var _ Notifier = Telegram{}
+
+// Notify hands the message to the bot and waits until it is sent, so failures go through the retry path.
+func (t Telegram) Notify(app handlers.Application, review handlers.UserReview) error {
+	log.Printf("[Telegram] Sending message to %d", t.ChatId)
+
+	var settings handlers.ChatSettings
+	datastore.Use(func(store *datastore.Datastore) {
+		settings = handlers.LoadChatSettings(store, t.ChatId)
+	})
+	message := app.ReviewMessage(t.ChatId, review, settings)
+
+	result := make(chan error, 1)
+	t.Resp <- trackReview(review, handlers.InThread(message, t.MessageThreadId), result)
+
+	err := <-result
+	if err == nil {
+		// the app may have been marked unreachable after it was scheduled, so the field is read again
+		datastore.Use(func(store *datastore.Datastore) {
+			if handlers.IsAppUnreachable(store, app.ID) {
+				handlers.ClearUnreachable(store, app.ID)
+			}
+		})
+	}
+	if te, ok := err.(tgbotapi.Error); ok && te.RetryAfter == 0 {
+		return PermanentError{err}
+	}
+
+	return err
+}
+
+func (t Telegram) Key() string {
+	return fmt.Sprintf("telegram:%d", t.ChatId)
+}
+
+// trackReview remembers which message the review was posted as, so it can be linked later.
+func trackReview(review handlers.UserReview, message tgbotapi.Chattable, result chan error) tgbotapi.Chattable {
+	return handlers.TrackedMessage{
+		Chattable: message,
+		OnFailed: func(err error) {
+			result <- err
+		},
+		OnSent: func(sent tgbotapi.Message) {
+			defer func() { result <- nil }()
+			if review.ReviewId == "" {
+				return
+			}
+
+			datastore.Use(func(store *datastore.Datastore) {
+				_, err := store.DB().Collection(collections.REVIEWS).UpdateOne(store.Context, bson.M{
+					"appid":    review.AppId,
+					"reviewid": review.ReviewId,
+				}, bson.M{
+					"$set": bson.M{
+						"chatid":    sent.Chat.ID,
+						"messageid": sent.MessageID,
+					},
+				})
+				utils.LogError(err)
+			})
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package notifier
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/utils"
+	"html/template"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var unsubscribeForm = template.Must(template.New("unsubscribe").Parse(`<form method="post">
+<input type="hidden" name="d" value="{{.Destination}}">
+<input type="hidden" name="t" value="{{.Token}}">
+<p>Stop receiving reviews at this address?</p>
+<button type="submit">Unsubscribe</button>
+</form>`))
+
+// UnsubscribeHandler removes the email destination from an unsubscribe link. Links are opened by mail scanners
+// and previews, so GET only asks for confirmation, the destination is removed by the POST of the form
+// or of the one-click unsubscribe of the mail client.
+func UnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(r.FormValue("d"))
+	token := r.FormValue("t")
+	if err != nil || token == "" {
+		http.Error(w, "Invalid link", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	switch r.Method {
+	case http.MethodGet:
+		utils.LogError(unsubscribeForm.Execute(w, struct {
+			Destination string
+			Token       string
+		}{id.Hex(), token}))
+		return
+	case http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var removed bool
+	datastore.Use(func(store *datastore.Datastore) {
+		res, err := store.DB().Collection(collections.APPS).UpdateOne(store.Context, bson.M{
+			"destinations": bson.M{
+				"$elemMatch": bson.M{"id": id, "type": "email", "secret": token},
+			},
+		}, bson.M{
+			"$pull": bson.M{
+				"destinations": bson.M{"id": id},
+			},
+		})
+		utils.LogError(err)
+		removed = err == nil && res.ModifiedCount > 0
+	})
+
+	if !removed {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<p>This address is already unsubscribed.</p>"))
+		return
+	}
+	w.Write([]byte("<p>You are unsubscribed and won't receive reviews anymore.</p>"))
+}
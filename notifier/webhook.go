@@ -0,0 +1,132 @@
+package notifier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"google-play-review-bot/handlers"
+	"strconv"
+	"time"
+)
+
+const (
+	WebhookSchemaVersion = 1
+
+	webhookEventHeader     = "X-Review-Bot-Event"
+	webhookTimestampHeader = "X-Review-Bot-Timestamp"
+	webhookSignatureHeader = "X-Review-Bot-Signature"
+)
+
+// Webhook posts reviews as JSON to any url, signed with the secret of the destination.
+// The signature is HMAC-SHA256 of "<timestamp>.<body>", hex encoded and prefixed with "sha256=".
+type Webhook struct {
+	destination handlers.Destination
+}
+
+var _ Notifier = Webhook{}
+
+func newWebhook(destination handlers.Destination) Notifier {
+	return Webhook{destination: destination}
+}
+
+// WebhookPayload is the body of webhook requests, any incompatible change must bump WebhookSchemaVersion.
+type WebhookPayload struct {
+	Version int           `json:"version"`
+	Event   string        `json:"event"`
+	App     WebhookApp    `json:"app"`
+	Review  WebhookReview `json:"review"`
+}
+
+type WebhookApp struct {
+	ID          string `json:"id"`
+	PackageName string `json:"packageName"`
+	Name        string `json:"name"`
+}
+
+type WebhookReview struct {
+	ID             string     `json:"id"`
+	ReviewId       string     `json:"reviewId"`
+	UserName       string     `json:"userName"`
+	Device         string     `json:"device,omitempty"`
+	SdkInt         int        `json:"sdkInt,omitempty"`
+	Text           string     `json:"text"`
+	OriginalText   string     `json:"originalText,omitempty"`
+	Language       string     `json:"language,omitempty"`
+	Time           *time.Time `json:"time,omitempty"`
+	Fetched        time.Time  `json:"fetched"`
+	Rating         int        `json:"rating"`
+	AppVersion     string     `json:"appVersion"`
+	AppBuildNumber int64      `json:"appBuildNumber,omitempty"`
+	ReplyText      string     `json:"replyText,omitempty"`
+	ReplyTime      *time.Time `json:"replyTime,omitempty"`
+}
+
+func newWebhookPayload(app handlers.Application, review handlers.UserReview) WebhookPayload {
+	event := "review.created"
+	if review.Edited {
+		event = "review.updated"
+	}
+
+	payload := WebhookPayload{
+		Version: WebhookSchemaVersion,
+		Event:   event,
+		App: WebhookApp{
+			ID:          app.ID.Hex(),
+			PackageName: app.PackageName,
+			Name:        app.GetName(),
+		},
+		Review: WebhookReview{
+			ReviewId:       review.ReviewId,
+			UserName:       review.UserName,
+			Device:         review.Device,
+			SdkInt:         review.SdkInt,
+			Text:           review.Text,
+			OriginalText:   review.OriginalText,
+			Language:       review.Language,
+			Fetched:        review.Fetched,
+			Rating:         review.Rating,
+			AppVersion:     review.AppVersion,
+			AppBuildNumber: review.AppBuildNumber,
+			ReplyText:      review.ReplyText,
+			ReplyTime:      review.ReplyTime,
+		},
+	}
+	if !review.ID.IsZero() {
+		payload.Review.ID = review.ID.Hex()
+	}
+	if !review.Time.IsZero() {
+		payload.Review.Time = &review.Time
+	}
+
+	return payload
+}
+
+func (w Webhook) Notify(app handlers.Application, review handlers.UserReview) error {
+	payload := newWebhookPayload(app, review)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return PermanentError{err}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	_, err = postJSON(w.destination.URL, map[string]string{
+		webhookEventHeader:     payload.Event,
+		webhookTimestampHeader: timestamp,
+		webhookSignatureHeader: "sha256=" + sign(w.destination.Secret, timestamp, body),
+	}, json.RawMessage(body))
+	return err
+}
+
+func (w Webhook) Key() string {
+	return "webhook:" + w.destination.ID.Hex()
+}
+
+func sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
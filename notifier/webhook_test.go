@@ -0,0 +1,19 @@
+package notifier
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"review":"Great app"}`, "9108143aef017d1418f8e39de698256a331bec77b4afeed7ec48932f5b369152"},
+		{"", "4bc5f74d868b97888288889c5d9d65df02526f94c1592a79fdf4fe8b26e311e5"},
+	}
+
+	for _, test := range tests {
+		if got := sign("secret", "1700000000", []byte(test.body)); got != test.want {
+			t.Errorf("sign(%q) = %s, want %s", test.body, got, test.want)
+		}
+	}
+}
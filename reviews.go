@@ -0,0 +1,49 @@
+package main
+
+import (
+	"google-play-review-bot/collections"
+	"google-play-review-bot/datastore"
+	"google-play-review-bot/handlers"
+	"google-play-review-bot/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// saveReview stores the review and returns it with its id, marked as edited when the user changed a known review.
+func saveReview(review handlers.UserReview) handlers.UserReview {
+	if review.ReviewId == "" {
+		return review
+	}
+	review.Fetched = time.Now()
+
+	datastore.Use(func(store *datastore.Datastore) {
+		newId := primitive.NewObjectID()
+		var existing handlers.UserReview
+		err := store.DB().Collection(collections.REVIEWS).FindOneAndUpdate(store.Context, bson.M{
+			"appid":    review.AppId,
+			"reviewid": review.ReviewId,
+		}, bson.M{
+			"$set":         review,
+			"$setOnInsert": bson.M{"_id": newId},
+		}, options.FindOneAndUpdate().
+			SetUpsert(true).
+			SetReturnDocument(options.Before).
+			SetProjection(bson.M{"_id": 1, "text": 1, "originaltext": 1, "rating": 1, "replytext": 1})).Decode(&existing)
+
+		switch err {
+		case nil:
+			review.ID = existing.ID
+			review.Edited = review.ChangedFrom(existing)
+		case mongo.ErrNoDocuments:
+			review.ID = newId
+		default:
+			utils.LogError(err)
+		}
+	})
+
+	return review
+}
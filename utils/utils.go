@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
 	"reflect"
 
 	"github.com/bugsnag/bugsnag-go"
@@ -16,6 +17,16 @@ func LogError(err error) {
 	}
 }
 
+// PublicUrl is where the http server of the bot is reachable, e.g. for unsubscribe links,
+// PUBLIC_URL is needed when updates are received with getUpdates, otherwise the webhook url is used.
+func PublicUrl() string {
+	if url := os.Getenv("PUBLIC_URL"); url != "" {
+		return url
+	}
+
+	return os.Getenv("WEBHOOK_URL")
+}
+
 func MakeError(v interface{}) error {
 	switch f := v.(type) {
 	case error: